@@ -55,7 +55,7 @@ import (
 
 	// Backend registrations
 	_ "github.com/docker/compose-cli/aci"
-	_ "github.com/docker/compose-cli/ecs"
+	"github.com/docker/compose-cli/ecs"
 	_ "github.com/docker/compose-cli/ecs/local"
 	_ "github.com/docker/compose-cli/local"
 )
@@ -250,6 +250,9 @@ func main() {
 	if ctype == store.AciContextType {
 		customizeCliForACI(command, proxy)
 	}
+	if ctype == store.EcsContextType {
+		customizeCliForECS(command, proxy)
+	}
 
 	root.AddCommand(command)
 
@@ -285,6 +288,41 @@ func customizeCliForACI(command *cobra.Command, proxy *api.ServiceProxy) {
 	}
 }
 
+func customizeCliForECS(command *cobra.Command, proxy *api.ServiceProxy) {
+	var dryRun, diff, cost bool
+	for _, c := range command.Commands() {
+		if c.Name() == "convert" {
+			c.Flags().BoolVar(&diff, "diff", false, "Report differences between the compose file, the deployed CloudFormation stack and live resources")
+			c.Flags().BoolVar(&cost, "cost", false, "Estimate the monthly cost of the application from a bundled AWS price table")
+			proxy.WithInterceptor(func(ctx context.Context, project *types.Project) {
+				if !diff && !cost {
+					return
+				}
+				if project.Extensions == nil {
+					project.Extensions = map[string]interface{}{}
+				}
+				if diff {
+					project.Extensions[ecs.ExtensionDiff] = true
+				}
+				if cost {
+					project.Extensions[ecs.ExtensionCost] = true
+				}
+			})
+		}
+		if c.Name() == "up" {
+			c.Flags().BoolVar(&dryRun, "dry-run", false, "Preview changes to the CloudFormation stack without applying them")
+			proxy.WithInterceptor(func(ctx context.Context, project *types.Project) {
+				if dryRun {
+					if project.Extensions == nil {
+						project.Extensions = map[string]interface{}{}
+					}
+					project.Extensions[ecs.ExtensionDryRun] = true
+				}
+			})
+		}
+	}
+}
+
 func handleError(
 	ctx context.Context,
 	err error,
@@ -17,49 +17,219 @@
 package ecs
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	applicationautoscaling2 "github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	cloudwatchapi "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/awslabs/goformation/v4/cloudformation"
 	"github.com/awslabs/goformation/v4/cloudformation/applicationautoscaling"
+	"github.com/awslabs/goformation/v4/cloudformation/cloudwatch"
 	"github.com/awslabs/goformation/v4/cloudformation/iam"
 	"github.com/compose-spec/compose-go/types"
 )
 
+const defaultScalingCooldown = 60
+
 type autoscalingConfig struct {
-	Memory int `json:"memory,omitempty"`
-	CPU    int `json:"cpu,omitempty"`
-	Min    int `json:"min,omitempty"`
-	Max    int `json:"max,omitempty"`
+	Memory           int                   `json:"memory,omitempty"`
+	CPU              int                   `json:"cpu,omitempty"`
+	Requests         int                   `json:"requests,omitempty"`
+	Metrics          []autoscalingMetric   `json:"metrics,omitempty"`
+	Min              int                   `json:"min,omitempty"`
+	Max              int                   `json:"max,omitempty"`
+	ScaleInCooldown  int                   `json:"scale_in_cooldown,omitempty"`
+	ScaleOutCooldown int                   `json:"scale_out_cooldown,omitempty"`
+	Schedule         []autoscalingSchedule `json:"schedule,omitempty"`
+	Steps            []autoscalingStep     `json:"steps,omitempty"`
 }
 
-func (b *ecsAPIService) createAutoscalingPolicy(project *types.Project, resources awsResources, template *cloudformation.Template, service types.ServiceConfig) error {
+// autoscalingMetric is a custom CloudWatch metric to track
+type autoscalingMetric struct {
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace"`
+	Statistic  string            `json:"statistic,omitempty"`
+	Unit       string            `json:"unit,omitempty"`
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+	Target     float64           `json:"target"`
+}
+
+// autoscalingSchedule sets service min and max replicas on schedule
+type autoscalingSchedule struct {
+	Name     string `json:"name,omitempty"`
+	Schedule string `json:"schedule"`
+	Min      *int   `json:"min"`
+	Max      *int   `json:"max"`
+}
+
+// autoscalingStep changes service replicas by adjustment when a CloudWatch metric crosses threshold. A positive adjustment
+// scales out while metric is above or equal to threshold, a negative one scales in while metric is below or equal to it
+type autoscalingStep struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	Statistic         string            `json:"statistic,omitempty"`
+	Unit              string            `json:"unit,omitempty"`
+	Dimensions        map[string]string `json:"dimensions,omitempty"`
+	Threshold         float64           `json:"threshold"`
+	Adjustment        int               `json:"adjustment"`
+	Period            int               `json:"period,omitempty"`
+	EvaluationPeriods int               `json:"evaluation_periods,omitempty"`
+}
+
+const (
+	defaultStepPeriod            = 60
+	defaultStepEvaluationPeriods = 1
+)
+
+var metricStatistics = []string{
+	applicationautoscaling2.MetricStatisticAverage,
+	applicationautoscaling2.MetricStatisticMinimum,
+	applicationautoscaling2.MetricStatisticMaximum,
+	applicationautoscaling2.MetricStatisticSampleCount,
+	applicationautoscaling2.MetricStatisticSum,
+}
+
+func getAutoscalingConfig(service types.ServiceConfig) (*autoscalingConfig, error) {
 	if service.Deploy == nil {
-		return nil
+		return nil, nil
 	}
 	v, ok := service.Deploy.Extensions[extensionAutoScaling]
 	if !ok {
-		return nil
+		return nil, nil
 	}
 
 	marshalled, err := json.Marshal(v)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var config autoscalingConfig
-	err = json.Unmarshal(marshalled, &config)
-	if err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(marshalled))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("service %s: invalid %s: %w", service.Name, extensionAutoScaling, err)
+	}
+	return &config, nil
+}
+
+// validate checks autoscaling configuration can be applied to service, so invalid settings are reported on convert
+// rather than by a failed stack deployment
+func (c autoscalingConfig) validate(service types.ServiceConfig, resources awsResources) error {
+	if c.Max == 0 {
+		return fmt.Errorf("%s MUST define max replicas", extensionAutoScaling)
+	}
+	if c.Min < 0 || c.Min > c.Max {
+		return fmt.Errorf("service %s: %s min replicas must be between 0 and max", service.Name, extensionAutoScaling)
+	}
+	if c.ScaleInCooldown < 0 || c.ScaleOutCooldown < 0 {
+		return fmt.Errorf("service %s: %s cooldowns can't be negative", service.Name, extensionAutoScaling)
+	}
+	if err := c.validateTargets(service, resources); err != nil {
 		return err
 	}
+	names := map[string]bool{}
+	for _, m := range c.Metrics {
+		if err := m.validate(service); err != nil {
+			return err
+		}
+		if names[normalizeResourceName(m.Name)] {
+			return fmt.Errorf("service %s: %s metric %s is defined more than once", service.Name, extensionAutoScaling, m.Name)
+		}
+		names[normalizeResourceName(m.Name)] = true
+	}
+	for _, s := range c.Schedule {
+		if err := s.validate(service); err != nil {
+			return err
+		}
+	}
+	steps := map[string]bool{}
+	for _, s := range c.Steps {
+		if err := s.validate(service); err != nil {
+			return err
+		}
+		if steps[s.resourceName(service)] {
+			return fmt.Errorf("service %s: %s step on metric %s is defined more than once in the same direction", service.Name, extensionAutoScaling, s.Name)
+		}
+		steps[s.resourceName(service)] = true
+	}
+	return nil
+}
+
+func (c autoscalingConfig) validateTargets(service types.ServiceConfig, resources awsResources) error {
+	if c.CPU == 0 && c.Memory == 0 && c.Requests == 0 && len(c.Metrics) == 0 && len(c.Schedule) == 0 && len(c.Steps) == 0 {
+		return fmt.Errorf("service %s: %s must define at least one target, step or schedule", service.Name, extensionAutoScaling)
+	}
+	if c.CPU < 0 || c.CPU > 100 || c.Memory < 0 || c.Memory > 100 {
+		return fmt.Errorf("service %s: %s cpu and memory targets must be percentages", service.Name, extensionAutoScaling)
+	}
+	if c.Requests < 0 {
+		return fmt.Errorf("service %s: %s requests target can't be negative", service.Name, extensionAutoScaling)
+	}
+	if c.Requests > 0 && (len(service.Ports) == 0 || resources.loadBalancerType != elbv2.LoadBalancerTypeEnumApplication) {
+		return fmt.Errorf("service %s: %s requests target requires service to be exposed by an Application Load Balancer",
+			service.Name, extensionAutoScaling)
+	}
+	return nil
+}
 
-	if config.Memory != 0 && config.CPU != 0 {
-		return fmt.Errorf("%s can't be set with both cpu and memory targets", extensionAutoScaling)
+func (m autoscalingMetric) validate(service types.ServiceConfig) error {
+	if m.Name == "" || m.Namespace == "" {
+		return fmt.Errorf("service %s: %s metrics must set name and namespace", service.Name, extensionAutoScaling)
 	}
-	if config.Max == 0 {
-		return fmt.Errorf("%s MUST define max replicas", extensionAutoScaling)
+	if m.Target <= 0 {
+		return fmt.Errorf("service %s: %s metric %s must set a positive target", service.Name, extensionAutoScaling, m.Name)
+	}
+	if m.Statistic != "" && !contains(metricStatistics, m.Statistic) {
+		return fmt.Errorf("service %s: %s metric %s statistic must be one of %s", service.Name, extensionAutoScaling, m.Name, strings.Join(metricStatistics, ", "))
 	}
+	return nil
+}
 
+func (s autoscalingSchedule) validate(service types.ServiceConfig) error {
+	expression := s.Schedule
+	if !(strings.HasPrefix(expression, "cron(") || strings.HasPrefix(expression, "rate(") || strings.HasPrefix(expression, "at(")) || !strings.HasSuffix(expression, ")") {
+		return fmt.Errorf("service %s: %s schedule must be a cron(), rate() or at() expression", service.Name, extensionAutoScaling)
+	}
+	if s.Min == nil || s.Max == nil {
+		return fmt.Errorf("service %s: %s schedule %s must set both min and max replicas", service.Name, extensionAutoScaling, expression)
+	}
+	if *s.Min < 0 || *s.Min > *s.Max {
+		return fmt.Errorf("service %s: %s schedule %s min replicas must be between 0 and max", service.Name, extensionAutoScaling, expression)
+	}
+	return nil
+}
+
+func (s autoscalingStep) validate(service types.ServiceConfig) error {
+	if s.Name == "" || s.Namespace == "" {
+		return fmt.Errorf("service %s: %s steps must set name and namespace", service.Name, extensionAutoScaling)
+	}
+	if s.Adjustment == 0 {
+		return fmt.Errorf("service %s: %s step on metric %s must set a non-zero adjustment", service.Name, extensionAutoScaling, s.Name)
+	}
+	if s.Statistic != "" && !contains(metricStatistics, s.Statistic) {
+		return fmt.Errorf("service %s: %s step on metric %s statistic must be one of %s", service.Name, extensionAutoScaling, s.Name, strings.Join(metricStatistics, ", "))
+	}
+	// CloudWatch alarms support high resolution periods of 10 or 30 seconds, otherwise a multiple of 60 seconds
+	if s.Period < 0 || (s.Period%60 != 0 && s.Period != 10 && s.Period != 30) {
+		return fmt.Errorf("service %s: %s step on metric %s period must be 10, 30 or a multiple of 60 seconds", service.Name, extensionAutoScaling, s.Name)
+	}
+	if s.EvaluationPeriods < 0 {
+		return fmt.Errorf("service %s: %s step on metric %s evaluation_periods can't be negative", service.Name, extensionAutoScaling, s.Name)
+	}
+	return nil
+}
+
+func (b *ecsAPIService) createAutoscalingPolicy(project *types.Project, resources awsResources, template *cloudformation.Template, service types.ServiceConfig) error {
+	config, err := getAutoscalingConfig(service)
+	if err != nil || config == nil {
+		return err
+	}
+	if err := config.validate(service, resources); err != nil {
+		return err
+	}
 	role := fmt.Sprintf("%sAutoScalingRole", normalizeResourceName(service.Name))
 	template.Resources[role] = &iam.Role{
 		AssumeRolePolicyDocument: ausocalingAssumeRolePolicyDocument,
@@ -96,33 +266,221 @@ func (b *ecsAPIService) createAutoscalingPolicy(project *types.Project, resource
 		ResourceId:                 resourceID,
 		RoleARN:                    cloudformation.GetAtt(role, "Arn"),
 		ScalableDimension:          applicationautoscaling2.ScalableDimensionEcsServiceDesiredCount,
+		ScheduledActions:           config.scheduledActions(service),
 		ServiceNamespace:           applicationautoscaling2.ServiceNamespaceEcs,
 		AWSCloudFormationDependsOn: []string{serviceResourceName(service.Name)},
 	}
 
-	var (
-		metric        = applicationautoscaling2.MetricTypeEcsserviceAverageCpuutilization
-		targetPercent = config.CPU
-	)
-	if config.Memory != 0 {
-		metric = applicationautoscaling2.MetricTypeEcsserviceAverageMemoryUtilization
-		targetPercent = config.Memory
+	for name, policy := range config.targetTrackingPolicies(service, resources) {
+		template.Resources[name] = &applicationautoscaling.ScalingPolicy{
+			PolicyType:                               "TargetTrackingScaling",
+			PolicyName:                               name,
+			ScalingTargetId:                          cloudformation.Ref(target),
+			TargetTrackingScalingPolicyConfiguration: policy,
+		}
+	}
+	for _, step := range config.Steps {
+		config.createStepScalingPolicy(service, template, target, step)
 	}
+	return nil
+}
+
+// cooldowns returns scale in and scale out cooldowns, in seconds
+func (c autoscalingConfig) cooldowns() (int, int) {
+	scaleIn, scaleOut := c.ScaleInCooldown, c.ScaleOutCooldown
+	if scaleIn == 0 {
+		scaleIn = defaultScalingCooldown
+	}
+	if scaleOut == 0 {
+		scaleOut = defaultScalingCooldown
+	}
+	return scaleIn, scaleOut
+}
 
-	policy := fmt.Sprintf("%sScalingPolicy", normalizeResourceName(service.Name))
+// resourceName returns the logical ID prefix for step scaling policy and alarm
+func (s autoscalingStep) resourceName(service types.ServiceConfig) string {
+	direction := "ScaleOut"
+	if s.Adjustment < 0 {
+		direction = "ScaleIn"
+	}
+	return fmt.Sprintf("%s%s%s", normalizeResourceName(service.Name), normalizeResourceName(s.Name), direction)
+}
+
+// createStepScalingPolicy declares a step scaling policy, triggered by a CloudWatch alarm on step metric
+func (c autoscalingConfig) createStepScalingPolicy(service types.ServiceConfig, template *cloudformation.Template, target string, step autoscalingStep) {
+	scaleIn, scaleOut := c.cooldowns()
+	cooldown, comparison, bound := scaleOut, cloudwatchapi.ComparisonOperatorGreaterThanOrEqualToThreshold, "MetricIntervalLowerBound"
+	if step.Adjustment < 0 {
+		cooldown, comparison, bound = scaleIn, cloudwatchapi.ComparisonOperatorLessThanOrEqualToThreshold, "MetricIntervalUpperBound"
+	}
+	statistic := step.Statistic
+	if statistic == "" {
+		statistic = applicationautoscaling2.MetricStatisticAverage
+	}
+	aggregation := statistic
+	if !contains([]string{applicationautoscaling2.MetricStatisticMinimum, applicationautoscaling2.MetricStatisticMaximum}, aggregation) {
+		aggregation = applicationautoscaling2.MetricStatisticAverage
+	}
+	period := step.Period
+	if period == 0 {
+		period = defaultStepPeriod
+	}
+	evaluationPeriods := step.EvaluationPeriods
+	if evaluationPeriods == 0 {
+		evaluationPeriods = defaultStepEvaluationPeriods
+	}
+
+	name := step.resourceName(service)
+	policy := fmt.Sprintf("%sPolicy", name)
 	template.Resources[policy] = &applicationautoscaling.ScalingPolicy{
-		PolicyType:                     "TargetTrackingScaling",
-		PolicyName:                     policy,
-		ScalingTargetId:                cloudformation.Ref(target),
-		StepScalingPolicyConfiguration: nil,
-		TargetTrackingScalingPolicyConfiguration: &applicationautoscaling.ScalingPolicy_TargetTrackingScalingPolicyConfiguration{
-			PredefinedMetricSpecification: &applicationautoscaling.ScalingPolicy_PredefinedMetricSpecification{
-				PredefinedMetricType: metric,
+		PolicyType:      "StepScaling",
+		PolicyName:      policy,
+		ScalingTargetId: cloudformation.Ref(target),
+		StepScalingPolicyConfiguration: &applicationautoscaling.ScalingPolicy_StepScalingPolicyConfiguration{
+			AdjustmentType:        applicationautoscaling2.AdjustmentTypeChangeInCapacity,
+			Cooldown:              cooldown,
+			MetricAggregationType: aggregation,
+			StepAdjustments: []applicationautoscaling.ScalingPolicy_StepAdjustment{
+				{ScalingAdjustment: step.Adjustment},
+			},
+		},
+		// step bounds are relative to alarm threshold, and 0 is omitted by goformation
+		AWSCloudFormationMetadata: map[string]interface{}{
+			extraProperties: map[string]interface{}{
+				"StepScalingPolicyConfiguration": map[string]interface{}{
+					"StepAdjustments": []interface{}{
+						map[string]interface{}{bound: 0},
+					},
+				},
 			},
-			ScaleOutCooldown: 60,
-			ScaleInCooldown:  60,
-			TargetValue:      float64(targetPercent),
 		},
 	}
-	return nil
+
+	var dimensions []cloudwatch.Alarm_Dimension
+	for name, value := range step.Dimensions {
+		dimensions = append(dimensions, cloudwatch.Alarm_Dimension{
+			Name:  name,
+			Value: value,
+		})
+	}
+	sort.Slice(dimensions, func(i, j int) bool {
+		return dimensions[i].Name < dimensions[j].Name
+	})
+	template.Resources[fmt.Sprintf("%sAlarm", name)] = &cloudwatch.Alarm{
+		AlarmActions:       []string{cloudformation.Ref(policy)},
+		AlarmDescription:   fmt.Sprintf("Scale %s service by %d replicas", service.Name, step.Adjustment),
+		ComparisonOperator: comparison,
+		Dimensions:         dimensions,
+		EvaluationPeriods:  evaluationPeriods,
+		MetricName:         step.Name,
+		Namespace:          step.Namespace,
+		Period:             period,
+		Statistic:          statistic,
+		Unit:               step.Unit,
+		// threshold can be 0, which is omitted by goformation
+		AWSCloudFormationMetadata: map[string]interface{}{
+			extraProperties: map[string]interface{}{
+				"Threshold": step.Threshold,
+			},
+		},
+	}
+}
+
+// targetTrackingPolicies returns the target tracking configurations for service, indexed by policy logical ID
+func (c autoscalingConfig) targetTrackingPolicies(service types.ServiceConfig, resources awsResources) map[string]*applicationautoscaling.ScalingPolicy_TargetTrackingScalingPolicyConfiguration {
+	scaleIn, scaleOut := c.cooldowns()
+	policy := func(target float64) *applicationautoscaling.ScalingPolicy_TargetTrackingScalingPolicyConfiguration {
+		return &applicationautoscaling.ScalingPolicy_TargetTrackingScalingPolicyConfiguration{
+			ScaleInCooldown:  scaleIn,
+			ScaleOutCooldown: scaleOut,
+			TargetValue:      target,
+		}
+	}
+	prefix := normalizeResourceName(service.Name)
+	policies := map[string]*applicationautoscaling.ScalingPolicy_TargetTrackingScalingPolicyConfiguration{}
+
+	// CPU or memory policy keeps the logical ID used when a single one of them could be set, so existing stacks are
+	// not updated. Memory only gets a dedicated ID when both are set
+	legacy := fmt.Sprintf("%sScalingPolicy", prefix)
+	if c.CPU != 0 {
+		p := policy(float64(c.CPU))
+		p.PredefinedMetricSpecification = &applicationautoscaling.ScalingPolicy_PredefinedMetricSpecification{
+			PredefinedMetricType: applicationautoscaling2.MetricTypeEcsserviceAverageCpuutilization,
+		}
+		policies[legacy] = p
+	}
+	if c.Memory != 0 {
+		p := policy(float64(c.Memory))
+		p.PredefinedMetricSpecification = &applicationautoscaling.ScalingPolicy_PredefinedMetricSpecification{
+			PredefinedMetricType: applicationautoscaling2.MetricTypeEcsserviceAverageMemoryUtilization,
+		}
+		name := legacy
+		if c.CPU != 0 {
+			name = fmt.Sprintf("%sMemoryScalingPolicy", prefix)
+		}
+		policies[name] = p
+	}
+	if c.Requests != 0 {
+		for _, port := range service.Ports {
+			p := policy(float64(c.Requests))
+			p.PredefinedMetricSpecification = &applicationautoscaling.ScalingPolicy_PredefinedMetricSpecification{
+				PredefinedMetricType: applicationautoscaling2.MetricTypeAlbrequestCountPerTarget,
+				ResourceLabel: cloudformation.Join("/", []string{
+					resources.loadBalancerFullName(),
+					cloudformation.GetAtt(targetGroupResourceName(service.Name, port), "TargetGroupFullName"),
+				}),
+			}
+			policies[fmt.Sprintf("%sRequests%dScalingPolicy", prefix, port.Published)] = p
+		}
+	}
+	for _, m := range c.Metrics {
+		p := policy(m.Target)
+		p.CustomizedMetricSpecification = m.specification()
+		policies[fmt.Sprintf("%s%sScalingPolicy", prefix, normalizeResourceName(m.Name))] = p
+	}
+	return policies
+}
+
+func (m autoscalingMetric) specification() *applicationautoscaling.ScalingPolicy_CustomizedMetricSpecification {
+	statistic := m.Statistic
+	if statistic == "" {
+		statistic = applicationautoscaling2.MetricStatisticAverage
+	}
+	var dimensions []applicationautoscaling.ScalingPolicy_MetricDimension
+	for name, value := range m.Dimensions {
+		dimensions = append(dimensions, applicationautoscaling.ScalingPolicy_MetricDimension{
+			Name:  name,
+			Value: value,
+		})
+	}
+	sort.Slice(dimensions, func(i, j int) bool {
+		return dimensions[i].Name < dimensions[j].Name
+	})
+	return &applicationautoscaling.ScalingPolicy_CustomizedMetricSpecification{
+		Dimensions: dimensions,
+		MetricName: m.Name,
+		Namespace:  m.Namespace,
+		Statistic:  statistic,
+		Unit:       m.Unit,
+	}
+}
+
+func (c autoscalingConfig) scheduledActions(service types.ServiceConfig) []applicationautoscaling.ScalableTarget_ScheduledAction {
+	var actions []applicationautoscaling.ScalableTarget_ScheduledAction
+	for i, s := range c.Schedule {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-schedule-%d", service.Name, i)
+		}
+		actions = append(actions, applicationautoscaling.ScalableTarget_ScheduledAction{
+			Schedule:            s.Schedule,
+			ScheduledActionName: name,
+			// zero capacities are omitted by goformation, and restored by marshall
+			ScalableTargetAction: &applicationautoscaling.ScalableTarget_ScalableTargetAction{
+				MaxCapacity: *s.Max,
+				MinCapacity: *s.Min,
+			},
+		})
+	}
+	return actions
 }
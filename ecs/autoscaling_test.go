@@ -17,9 +17,13 @@
 package ecs
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/awslabs/goformation/v4/cloudformation"
 	autoscaling "github.com/awslabs/goformation/v4/cloudformation/applicationautoscaling"
+	"github.com/awslabs/goformation/v4/cloudformation/cloudwatch"
+	"github.com/sanathkr/go-yaml"
 	"gotest.tools/v3/assert"
 )
 
@@ -41,3 +45,266 @@ services:
 	assert.Check(t, policy != nil)                                                              //nolint:staticcheck
 	assert.Check(t, policy.TargetTrackingScalingPolicyConfiguration.TargetValue == float64(75)) //nolint:staticcheck
 }
+
+func TestAutoScalingMemory(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    deploy:
+      x-aws-autoscaling:
+        memory: 80
+        max: 10
+`, nil, useDefaultVPC)
+	assert.Check(t, template.Resources["FooMemoryScalingPolicy"] == nil)
+	policy := template.Resources["FooScalingPolicy"].(*autoscaling.ScalingPolicy).TargetTrackingScalingPolicyConfiguration
+	assert.Equal(t, policy.PredefinedMetricSpecification.PredefinedMetricType, "ECSServiceAverageMemoryUtilization")
+	assert.Equal(t, policy.TargetValue, float64(80))
+}
+
+func TestAutoScalingMultipleTargets(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    ports:
+      - 80:8080
+    deploy:
+      x-aws-autoscaling:
+        min: 1
+        max: 10
+        cpu: 75
+        memory: 80
+        requests: 1000
+        scale_in_cooldown: 300
+        metrics:
+          - name: queue-depth
+            namespace: MyApp
+            dimensions:
+              Queue: jobs
+            target: 100
+`, nil, useDefaultVPC)
+	cpu := template.Resources["FooScalingPolicy"].(*autoscaling.ScalingPolicy).TargetTrackingScalingPolicyConfiguration
+	assert.Equal(t, cpu.PredefinedMetricSpecification.PredefinedMetricType, "ECSServiceAverageCPUUtilization")
+	assert.Equal(t, cpu.ScaleInCooldown, 300)
+	assert.Equal(t, cpu.ScaleOutCooldown, 60)
+
+	memory := template.Resources["FooMemoryScalingPolicy"].(*autoscaling.ScalingPolicy).TargetTrackingScalingPolicyConfiguration
+	assert.Equal(t, memory.PredefinedMetricSpecification.PredefinedMetricType, "ECSServiceAverageMemoryUtilization")
+	assert.Equal(t, memory.TargetValue, float64(80))
+
+	requests := template.Resources["FooRequests80ScalingPolicy"].(*autoscaling.ScalingPolicy).TargetTrackingScalingPolicyConfiguration
+	assert.DeepEqual(t, requests.PredefinedMetricSpecification, &autoscaling.ScalingPolicy_PredefinedMetricSpecification{
+		PredefinedMetricType: "ALBRequestCountPerTarget",
+		ResourceLabel: cloudformation.Join("/", []string{
+			cloudformation.GetAtt("LoadBalancer", "LoadBalancerFullName"),
+			cloudformation.GetAtt("FooTCP80TargetGroup", "TargetGroupFullName"),
+		}),
+	})
+
+	custom := template.Resources["FooQueuedepthScalingPolicy"].(*autoscaling.ScalingPolicy).TargetTrackingScalingPolicyConfiguration
+	assert.DeepEqual(t, custom.CustomizedMetricSpecification, &autoscaling.ScalingPolicy_CustomizedMetricSpecification{
+		Dimensions: []autoscaling.ScalingPolicy_MetricDimension{
+			{Name: "Queue", Value: "jobs"},
+		},
+		MetricName: "queue-depth",
+		Namespace:  "MyApp",
+		Statistic:  "Average",
+	})
+	assert.Equal(t, custom.TargetValue, float64(100))
+}
+
+func TestAutoScalingSchedule(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    deploy:
+      x-aws-autoscaling:
+        min: 1
+        max: 4
+        schedule:
+          - name: night
+            schedule: "cron(0 22 * * ? *)"
+            min: 0
+            max: 0
+          - schedule: "cron(0 7 * * ? *)"
+            min: 1
+            max: 4
+`, nil, useDefaultVPC)
+	target := template.Resources["FooScalableTarget"].(*autoscaling.ScalableTarget)
+	assert.DeepEqual(t, target.ScheduledActions, []autoscaling.ScalableTarget_ScheduledAction{
+		{
+			Schedule:             "cron(0 22 * * ? *)",
+			ScheduledActionName:  "night",
+			ScalableTargetAction: &autoscaling.ScalableTarget_ScalableTargetAction{},
+		},
+		{
+			Schedule:             "cron(0 7 * * ? *)",
+			ScheduledActionName:  "foo-schedule-1",
+			ScalableTargetAction: &autoscaling.ScalableTarget_ScalableTargetAction{MinCapacity: 1, MaxCapacity: 4},
+		},
+	})
+
+	for name := range template.Resources {
+		assert.Check(t, name != "FooScalingPolicy")
+	}
+	bytes, err := marshall(template, "yaml")
+	assert.NilError(t, err)
+	var marshalled struct {
+		Resources map[string]struct {
+			Properties struct {
+				ScheduledActions []struct {
+					ScalableTargetAction map[string]int `yaml:"ScalableTargetAction"`
+				} `yaml:"ScheduledActions"`
+			} `yaml:"Properties"`
+		} `yaml:"Resources"`
+	}
+	assert.NilError(t, yaml.Unmarshal(bytes, &marshalled))
+	actions := marshalled.Resources["FooScalableTarget"].Properties.ScheduledActions
+	assert.DeepEqual(t, actions[0].ScalableTargetAction, map[string]int{"MinCapacity": 0, "MaxCapacity": 0})
+}
+
+func TestAutoScalingSteps(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    deploy:
+      x-aws-autoscaling:
+        min: 0
+        max: 10
+        scale_in_cooldown: 300
+        steps:
+          - name: ApproximateNumberOfMessagesVisible
+            namespace: AWS/SQS
+            dimensions:
+              QueueName: jobs
+            threshold: 100
+            adjustment: 2
+          - name: ApproximateNumberOfMessagesVisible
+            namespace: AWS/SQS
+            dimensions:
+              QueueName: jobs
+            statistic: Maximum
+            threshold: 0
+            adjustment: -1
+            period: 300
+            evaluation_periods: 3
+`, nil, useDefaultVPC)
+	scaleOut := template.Resources["FooApproximateNumberOfMessagesVisibleScaleOutPolicy"].(*autoscaling.ScalingPolicy)
+	assert.Equal(t, scaleOut.PolicyType, "StepScaling")
+	assert.Equal(t, scaleOut.ScalingTargetId, cloudformation.Ref("FooScalableTarget"))
+	assert.DeepEqual(t, scaleOut.StepScalingPolicyConfiguration, &autoscaling.ScalingPolicy_StepScalingPolicyConfiguration{
+		AdjustmentType:        "ChangeInCapacity",
+		Cooldown:              60,
+		MetricAggregationType: "Average",
+		StepAdjustments:       []autoscaling.ScalingPolicy_StepAdjustment{{ScalingAdjustment: 2}},
+	})
+	alarm := template.Resources["FooApproximateNumberOfMessagesVisibleScaleOutAlarm"].(*cloudwatch.Alarm)
+	assert.DeepEqual(t, alarm.AlarmActions, []string{cloudformation.Ref("FooApproximateNumberOfMessagesVisibleScaleOutPolicy")})
+	assert.Equal(t, alarm.ComparisonOperator, "GreaterThanOrEqualToThreshold")
+	assert.DeepEqual(t, alarm.Dimensions, []cloudwatch.Alarm_Dimension{{Name: "QueueName", Value: "jobs"}})
+	assert.Equal(t, alarm.Period, 60)
+	assert.Equal(t, alarm.EvaluationPeriods, 1)
+
+	scaleIn := template.Resources["FooApproximateNumberOfMessagesVisibleScaleInPolicy"].(*autoscaling.ScalingPolicy)
+	assert.Equal(t, scaleIn.StepScalingPolicyConfiguration.Cooldown, 300)
+	assert.Equal(t, scaleIn.StepScalingPolicyConfiguration.MetricAggregationType, "Maximum")
+	alarm = template.Resources["FooApproximateNumberOfMessagesVisibleScaleInAlarm"].(*cloudwatch.Alarm)
+	assert.Equal(t, alarm.ComparisonOperator, "LessThanOrEqualToThreshold")
+	assert.Equal(t, alarm.Period, 300)
+	assert.Equal(t, alarm.EvaluationPeriods, 3)
+
+	// zero threshold and step bounds are omitted by goformation, and restored by marshall
+	bytes, err := marshall(template, "yaml")
+	assert.NilError(t, err)
+	var marshalled struct {
+		Resources map[string]struct {
+			Properties struct {
+				Threshold                      *float64 `yaml:"Threshold"`
+				StepScalingPolicyConfiguration struct {
+					StepAdjustments []map[string]int `yaml:"StepAdjustments"`
+				} `yaml:"StepScalingPolicyConfiguration"`
+			} `yaml:"Properties"`
+		} `yaml:"Resources"`
+	}
+	assert.NilError(t, yaml.Unmarshal(bytes, &marshalled))
+	threshold := marshalled.Resources["FooApproximateNumberOfMessagesVisibleScaleInAlarm"].Properties.Threshold
+	assert.Assert(t, threshold != nil)
+	assert.Equal(t, *threshold, float64(0))
+	assert.DeepEqual(t, marshalled.Resources["FooApproximateNumberOfMessagesVisibleScaleOutPolicy"].Properties.StepScalingPolicyConfiguration.StepAdjustments,
+		[]map[string]int{{"MetricIntervalLowerBound": 0, "ScalingAdjustment": 2}})
+	assert.DeepEqual(t, marshalled.Resources["FooApproximateNumberOfMessagesVisibleScaleInPolicy"].Properties.StepScalingPolicyConfiguration.StepAdjustments,
+		[]map[string]int{{"MetricIntervalUpperBound": 0, "ScalingAdjustment": -1}})
+}
+
+func TestAutoScalingValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		autoscaling string
+		err         string
+	}{
+		{
+			name:        "no target",
+			autoscaling: "{max: 3}",
+			err:         "service foo: x-aws-autoscaling must define at least one target, step or schedule",
+		},
+		{
+			name:        "min greater than max",
+			autoscaling: "{min: 5, max: 3, cpu: 50}",
+			err:         "service foo: x-aws-autoscaling min replicas must be between 0 and max",
+		},
+		{
+			name:        "unknown attribute",
+			autoscaling: "{max: 3, cpus: 50}",
+			err:         `service foo: invalid x-aws-autoscaling: json: unknown field "cpus"`,
+		},
+		{
+			name:        "requests without load balancer",
+			autoscaling: "{max: 3, requests: 100}",
+			err:         "service foo: x-aws-autoscaling requests target requires service to be exposed by an Application Load Balancer",
+		},
+		{
+			name:        "invalid statistic",
+			autoscaling: "{max: 3, metrics: [{name: depth, namespace: app, target: 10, statistic: Median}]}",
+			err:         "service foo: x-aws-autoscaling metric depth statistic must be one of Average, Minimum, Maximum, SampleCount, Sum",
+		},
+		{
+			name:        "invalid schedule",
+			autoscaling: `{max: 3, schedule: [{schedule: "0 22 * * *", min: 0, max: 0}]}`,
+			err:         "service foo: x-aws-autoscaling schedule must be a cron(), rate() or at() expression",
+		},
+		{
+			name:        "schedule without max",
+			autoscaling: `{max: 3, schedule: [{schedule: "cron(0 22 * * ? *)", min: 0}]}`,
+			err:         "service foo: x-aws-autoscaling schedule cron(0 22 * * ? *) must set both min and max replicas",
+		},
+		{
+			name:        "step without adjustment",
+			autoscaling: "{max: 3, steps: [{name: depth, namespace: app, threshold: 10}]}",
+			err:         "service foo: x-aws-autoscaling step on metric depth must set a non-zero adjustment",
+		},
+		{
+			name:        "step invalid period",
+			autoscaling: "{max: 3, steps: [{name: depth, namespace: app, threshold: 10, adjustment: 1, period: 45}]}",
+			err:         "service foo: x-aws-autoscaling step on metric depth period must be 10, 30 or a multiple of 60 seconds",
+		},
+		{
+			name:        "duplicate step",
+			autoscaling: "{max: 3, steps: [{name: depth, namespace: app, threshold: 10, adjustment: 1}, {name: depth, namespace: app, threshold: 20, adjustment: 2}]}",
+			err:         "service foo: x-aws-autoscaling step on metric depth is defined more than once in the same direction",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    deploy:
+      x-aws-autoscaling: `+tt.autoscaling+`
+`, errors.New(tt.err), useDefaultVPC)
+		})
+	}
+}
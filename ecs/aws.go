@@ -21,6 +21,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ecs"
+	clitypes "github.com/docker/cli/cli/config/types"
 	"github.com/docker/compose/v2/pkg/api"
 
 	"github.com/docker/compose-cli/api/secrets"
@@ -42,11 +43,14 @@ type API interface {
 	GetDefaultVPC(ctx context.Context) (string, error)
 	GetSubNets(ctx context.Context, vpcID string) ([]awsResource, error)
 	IsPublicSubnet(ctx context.Context, subNetID string) (bool, error)
+	HasNATRoute(ctx context.Context, subNetID string) (bool, error)
 	GetRoleArn(ctx context.Context, name string) (string, error)
 	StackExists(ctx context.Context, name string) (bool, error)
 	CreateStack(ctx context.Context, name string, region string, template []byte) error
 	CreateChangeSet(ctx context.Context, name string, region string, template []byte) (string, error)
 	UpdateStack(ctx context.Context, changeset string) error
+	DescribeChangeSet(ctx context.Context, changeset string) ([]stackChange, error)
+	DeleteChangeSet(ctx context.Context, changeset string) error
 	WaitStackComplete(ctx context.Context, name string, operation int) error
 	GetStackID(ctx context.Context, name string) (string, error)
 	ListStacks(ctx context.Context) ([]api.Stack, error)
@@ -55,9 +59,16 @@ type API interface {
 	ListStackServices(ctx context.Context, stack string) ([]string, error)
 	GetServiceTasks(ctx context.Context, cluster string, service string, stopped bool) ([]*ecs.Task, error)
 	GetTaskStoppedReason(ctx context.Context, cluster string, taskArn string) (string, error)
+	CreateDeployment(ctx context.Context, application string, group string, appSpec string) (string, error)
+	GetDeploymentStatus(ctx context.Context, id string) (string, string, error)
+	DescribeServiceEvents(ctx context.Context, cluster string, serviceArns []string) (map[string]serviceEvents, error)
 	DescribeStackEvents(ctx context.Context, stackID string) ([]*cloudformation.StackEvent, error)
+	DescribeStackEventsSince(ctx context.Context, stackID string, lastEventID string) ([]*cloudformation.StackEvent, error)
 	ListStackParameters(ctx context.Context, name string) (map[string]string, error)
+	ListStackOutputs(ctx context.Context, name string) (map[string]string, error)
 	ListStackResources(ctx context.Context, name string) (stackResources, error)
+	GetStackTemplate(ctx context.Context, name string) ([]byte, error)
+	DetectStackDrift(ctx context.Context, name string) ([]resourceDrift, error)
 	DeleteStack(ctx context.Context, name string) error
 	CreateSecret(ctx context.Context, secret secrets.Secret) (string, error)
 	InspectSecret(ctx context.Context, id string) (secrets.Secret, error)
@@ -65,7 +76,7 @@ type API interface {
 	DeleteSecret(ctx context.Context, id string, recover bool) error
 	GetLogs(ctx context.Context, name string, consumer func(container string, service string, message string), follow bool) error
 	DescribeService(ctx context.Context, cluster string, arn string) (api.ServiceStatus, error)
-	DescribeServiceTasks(ctx context.Context, cluster string, project string, service string) ([]api.ContainerSummary, error)
+	DescribeServiceTasks(ctx context.Context, cluster string, project string, service string, stopped bool) ([]api.ContainerSummary, error)
 	getURLWithPortMapping(ctx context.Context, targetGroupArns []string) ([]api.PortPublisher, error)
 	ListTasks(ctx context.Context, cluster string, family string) ([]string, error)
 	GetPublicIPs(ctx context.Context, interfaces ...string) (map[string]string, error)
@@ -79,4 +90,7 @@ type API interface {
 	ListFileSystems(ctx context.Context, tags map[string]string) ([]awsResource, error)
 	CreateFileSystem(ctx context.Context, tags map[string]string, options VolumeCreateOptions) (awsResource, error)
 	DeleteFileSystem(ctx context.Context, id string) error
+	ResolveRepository(ctx context.Context, name string, tags map[string]string) (string, error)
+	GetRegistryCredentials(ctx context.Context) (clitypes.AuthConfig, error)
+	GetImageDigest(ctx context.Context, repository string, tag string) (string, error)
 }
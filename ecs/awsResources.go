@@ -19,7 +19,6 @@ package ecs
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws/arn"
@@ -37,13 +36,17 @@ import (
 
 // awsResources hold the AWS component being used or created to support services definition
 type awsResources struct {
-	vpc              string // shouldn't this also be an awsResource ?
-	subnets          []awsResource
-	cluster          awsResource
-	loadBalancer     awsResource
-	loadBalancerType string
-	securityGroups   map[string]string
-	filesystems      map[string]awsResource
+	vpc                  string // shouldn't this also be an awsResource ?
+	subnets              []awsResource
+	cluster              awsResource
+	loadBalancer         awsResource
+	loadBalancerType     string
+	loadBalancerDNS      string
+	loadBalancerSubnets  []awsResource
+	internalLoadBalancer bool
+	disablePublicIP      bool
+	securityGroups       map[string]string
+	filesystems          map[string]awsResource
 }
 
 func (r *awsResources) serviceSecurityGroups(service types.ServiceConfig) []string {
@@ -54,6 +57,25 @@ func (r *awsResources) serviceSecurityGroups(service types.ServiceConfig) []stri
 	return groups
 }
 
+func (r *awsResources) loadBalancerDNSName() string {
+	if lb, ok := r.loadBalancer.(cloudformationARNResource); ok {
+		return cloudformation.GetAtt(lb.logicalName, "DNSName")
+	}
+	return r.loadBalancerDNS
+}
+
+// loadBalancerFullName returns the load balancer name, as used by CloudWatch metrics dimensions
+func (r *awsResources) loadBalancerFullName() string {
+	if lb, ok := r.loadBalancer.(cloudformationARNResource); ok {
+		return cloudformation.GetAtt(lb.logicalName, "LoadBalancerFullName")
+	}
+	parsed, err := arn.Parse(r.loadBalancer.ARN())
+	if err != nil {
+		return r.loadBalancer.ID()
+	}
+	return strings.TrimPrefix(parsed.Resource, "loadbalancer/")
+}
+
 func (r *awsResources) allSecurityGroups() []string {
 	var securityGroups []string
 	for _, r := range r.securityGroups {
@@ -70,6 +92,17 @@ func (r *awsResources) subnetsIDs() []string {
 	return ids
 }
 
+func (r *awsResources) loadBalancerSubnetsIDs() []string {
+	if r.loadBalancerSubnets == nil {
+		return r.subnetsIDs()
+	}
+	var ids []string
+	for _, r := range r.loadBalancerSubnets {
+		ids = append(ids, r.ID())
+	}
+	return ids
+}
+
 // awsResource is abstract representation for any (existing or future) AWS resource that we can refer both by ID or full ARN
 type awsResource interface {
 	ARN() string
@@ -179,24 +212,27 @@ func (b *ecsAPIService) parseVPCExtension(ctx context.Context, project *types.Pr
 			if r.vpc != vpc {
 				return fmt.Errorf("load balancer set by %s is attached to VPC %s", extensionLoadBalancer, r.vpc)
 			}
-			return nil
-		}
-
-		err = b.aws.CheckVPC(ctx, vpc)
-		if err != nil {
-			return err
+			if !hasSubnetsSelection(project) {
+				return nil
+			}
+		} else {
+			err = b.aws.CheckVPC(ctx, vpc)
+			if err != nil {
+				return err
+			}
 		}
-
 	} else {
-		if r.vpc != "" {
+		if r.vpc != "" && !hasSubnetsSelection(project) {
 			return nil
 		}
-
-		defaultVPC, err := b.aws.GetDefaultVPC(ctx)
-		if err != nil {
-			return err
+		vpc = r.vpc
+		if vpc == "" {
+			defaultVPC, err := b.aws.GetDefaultVPC(ctx)
+			if err != nil {
+				return err
+			}
+			vpc = defaultVPC
 		}
-		vpc = defaultVPC
 	}
 
 	subNets, err := b.aws.GetSubNets(ctx, vpc)
@@ -204,24 +240,8 @@ func (b *ecsAPIService) parseVPCExtension(ctx context.Context, project *types.Pr
 		return err
 	}
 
-	var publicSubNets []awsResource
-	for _, subNet := range subNets {
-		isPublic, err := b.aws.IsPublicSubnet(ctx, subNet.ID())
-		if err != nil {
-			return err
-		}
-		if isPublic {
-			publicSubNets = append(publicSubNets, subNet)
-		}
-	}
-
-	if len(publicSubNets) < 2 {
-		return fmt.Errorf("VPC %s should have at least 2 associated public subnets in different availability zones", vpc)
-	}
-
 	r.vpc = vpc
-	r.subnets = subNets
-	return nil
+	return b.selectSubnets(ctx, project, subNets, r)
 }
 
 func (b *ecsAPIService) parseLoadBalancerExtension(ctx context.Context, project *types.Project, r *awsResources) error {
@@ -237,11 +257,17 @@ func (b *ecsAPIService) parseLoadBalancerExtension(ctx context.Context, project
 			return fmt.Errorf("load balancer %q is of type %s, project require a %s", nameOrArn, loadBalancerType, required)
 		}
 
+		dnsName, err := b.aws.GetLoadBalancerURL(ctx, loadBalancer.ARN())
+		if err != nil {
+			return err
+		}
+
 		r.loadBalancer = loadBalancer
 		r.loadBalancerType = loadBalancerType
+		r.loadBalancerDNS = dnsName
 		r.vpc = vpc
 		r.subnets = subnets
-		return err
+		return nil
 	}
 	return nil
 }
@@ -321,10 +347,17 @@ func (b *ecsAPIService) ensureCluster(r *awsResources, project *types.Project, t
 	if r.cluster != nil {
 		return
 	}
-	template.Resources["Cluster"] = &ecs.Cluster{
+	cluster := &ecs.Cluster{
 		ClusterName: project.Name,
 		Tags:        projectTags(project),
 	}
+	if requireFargateCapacityProviders(project) {
+		cluster.CapacityProviders = []string{fargateCapacityProvider, fargateSpotCapacityProvider}
+	}
+	if useServiceConnect(project) {
+		cluster.AWSCloudFormationMetadata = serviceConnectDefaults()
+	}
+	template.Resources["Cluster"] = cluster
 	r.cluster = cloudformationResource{logicalName: "Cluster"}
 }
 
@@ -357,42 +390,34 @@ func (b *ecsAPIService) ensureNetworks(r *awsResources, project *types.Project,
 
 func (b *ecsAPIService) ensureVolumes(r *awsResources, project *types.Project, template *cloudformation.Template) error {
 	for name, volume := range project.Volumes {
+		options, err := getFileSystemOptions(name, volume)
+		if err != nil {
+			return err
+		}
 		if _, ok := r.filesystems[name]; ok {
 			continue
 		}
 
 		var backupPolicy *efs.FileSystem_BackupPolicy
-		if backup, ok := volume.DriverOpts["backup_policy"]; ok {
+		if options.BackupPolicy != "" {
 			backupPolicy = &efs.FileSystem_BackupPolicy{
-				Status: backup,
+				Status: options.BackupPolicy,
 			}
 		}
 
 		var lifecyclePolicies []efs.FileSystem_LifecyclePolicy
-		if policy, ok := volume.DriverOpts["lifecycle_policy"]; ok {
+		if options.TransitionToIA != "" {
 			lifecyclePolicies = append(lifecyclePolicies, efs.FileSystem_LifecyclePolicy{
-				TransitionToIA: strings.TrimSpace(policy),
+				TransitionToIA: options.TransitionToIA,
 			})
 		}
 
-		var provisionedThroughputInMibps float64
-		if t, ok := volume.DriverOpts["provisioned_throughput"]; ok {
-			v, err := strconv.ParseFloat(t, 64)
-			if err != nil {
-				return err
-			}
-			provisionedThroughputInMibps = v
-		}
-
-		var performanceMode = volume.DriverOpts["performance_mode"]
-		var throughputMode = volume.DriverOpts["throughput_mode"]
-		var kmsKeyID = volume.DriverOpts["kms_key_id"]
-
 		n := volumeResourceName(name)
 		template.Resources[n] = &efs.FileSystem{
-			BackupPolicy:     backupPolicy,
-			Encrypted:        true,
-			FileSystemPolicy: nil,
+			AWSCloudFormationMetadata: transitionToPrimaryStorageClass(options, len(lifecyclePolicies)),
+			BackupPolicy:              backupPolicy,
+			Encrypted:                 true,
+			FileSystemPolicy:          nil,
 			FileSystemTags: []efs.FileSystem_ElasticFileSystemTag{
 				{
 					Key:   api.ProjectLabel,
@@ -407,11 +432,11 @@ func (b *ecsAPIService) ensureVolumes(r *awsResources, project *types.Project, t
 					Value: volume.Name,
 				},
 			},
-			KmsKeyId:                        kmsKeyID,
+			KmsKeyId:                        options.KmsKeyID,
 			LifecyclePolicies:               lifecyclePolicies,
-			PerformanceMode:                 performanceMode,
-			ProvisionedThroughputInMibps:    provisionedThroughputInMibps,
-			ThroughputMode:                  throughputMode,
+			PerformanceMode:                 options.PerformanceMode,
+			ProvisionedThroughputInMibps:    options.ProvisionedThroughput,
+			ThroughputMode:                  options.ThroughputMode,
 			AWSCloudFormationDeletionPolicy: "Retain",
 		}
 		r.filesystems[name] = cloudformationResource{logicalName: n}
@@ -448,10 +473,15 @@ func (b *ecsAPIService) ensureLoadBalancer(r *awsResources, project *types.Proje
 			})
 	}
 
+	scheme := elbv2.LoadBalancerSchemeEnumInternetFacing
+	if r.internalLoadBalancer {
+		scheme = elbv2.LoadBalancerSchemeEnumInternal
+	}
+
 	template.Resources["LoadBalancer"] = &elasticloadbalancingv2.LoadBalancer{
-		Scheme:                 elbv2.LoadBalancerSchemeEnumInternetFacing,
+		Scheme:                 scheme,
 		SecurityGroups:         securityGroups,
-		Subnets:                r.subnetsIDs(),
+		Subnets:                r.loadBalancerSubnetsIDs(),
 		Tags:                   projectTags(project),
 		Type:                   balancerType,
 		LoadBalancerAttributes: loadBalancerAttributes,
@@ -488,7 +518,7 @@ func portIsHTTP(it types.ServicePortConfig) bool {
 		protocol := v.(string)
 		return protocol == "http" || protocol == "https"
 	}
-	return it.Target == 80 || it.Target == 443
+	return it.Published == 80 || it.Published == 443
 }
 
 // predicate[types.ServiceConfig]
@@ -10,6 +10,7 @@ import (
 
 	cloudformation "github.com/aws/aws-sdk-go/service/cloudformation"
 	ecs "github.com/aws/aws-sdk-go/service/ecs"
+	types "github.com/docker/cli/cli/config/types"
 	compose "github.com/docker/compose/v2/pkg/api"
 	gomock "github.com/golang/mock/gomock"
 
@@ -97,6 +98,21 @@ func (mr *MockAPIMockRecorder) CreateCluster(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCluster", reflect.TypeOf((*MockAPI)(nil).CreateCluster), arg0, arg1)
 }
 
+// CreateDeployment mocks base method
+func (m *MockAPI) CreateDeployment(arg0 context.Context, arg1, arg2, arg3 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeployment", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDeployment indicates an expected call of CreateDeployment
+func (mr *MockAPIMockRecorder) CreateDeployment(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeployment", reflect.TypeOf((*MockAPI)(nil).CreateDeployment), arg0, arg1, arg2, arg3)
+}
+
 // CreateFileSystem mocks base method
 func (m *MockAPI) CreateFileSystem(arg0 context.Context, arg1 map[string]string, arg2 VolumeCreateOptions) (awsResource, error) {
 	m.ctrl.T.Helper()
@@ -169,6 +185,20 @@ func (mr *MockAPIMockRecorder) DeleteCapacityProvider(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCapacityProvider", reflect.TypeOf((*MockAPI)(nil).DeleteCapacityProvider), arg0, arg1)
 }
 
+// DeleteChangeSet mocks base method
+func (m *MockAPI) DeleteChangeSet(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChangeSet", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChangeSet indicates an expected call of DeleteChangeSet
+func (mr *MockAPIMockRecorder) DeleteChangeSet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChangeSet", reflect.TypeOf((*MockAPI)(nil).DeleteChangeSet), arg0, arg1)
+}
+
 // DeleteFileSystem mocks base method
 func (m *MockAPI) DeleteFileSystem(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -211,6 +241,21 @@ func (mr *MockAPIMockRecorder) DeleteStack(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStack", reflect.TypeOf((*MockAPI)(nil).DeleteStack), arg0, arg1)
 }
 
+// DescribeChangeSet mocks base method
+func (m *MockAPI) DescribeChangeSet(arg0 context.Context, arg1 string) ([]stackChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeChangeSet", arg0, arg1)
+	ret0, _ := ret[0].([]stackChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeChangeSet indicates an expected call of DescribeChangeSet
+func (mr *MockAPIMockRecorder) DescribeChangeSet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeChangeSet", reflect.TypeOf((*MockAPI)(nil).DescribeChangeSet), arg0, arg1)
+}
+
 // DescribeService mocks base method
 func (m *MockAPI) DescribeService(arg0 context.Context, arg1, arg2 string) (compose.ServiceStatus, error) {
 	m.ctrl.T.Helper()
@@ -226,19 +271,34 @@ func (mr *MockAPIMockRecorder) DescribeService(arg0, arg1, arg2 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeService", reflect.TypeOf((*MockAPI)(nil).DescribeService), arg0, arg1, arg2)
 }
 
+// DescribeServiceEvents mocks base method
+func (m *MockAPI) DescribeServiceEvents(arg0 context.Context, arg1 string, arg2 []string) (map[string]serviceEvents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeServiceEvents", arg0, arg1, arg2)
+	ret0, _ := ret[0].(map[string]serviceEvents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeServiceEvents indicates an expected call of DescribeServiceEvents
+func (mr *MockAPIMockRecorder) DescribeServiceEvents(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeServiceEvents", reflect.TypeOf((*MockAPI)(nil).DescribeServiceEvents), arg0, arg1, arg2)
+}
+
 // DescribeServiceTasks mocks base method
-func (m *MockAPI) DescribeServiceTasks(arg0 context.Context, arg1, arg2, arg3 string) ([]compose.ContainerSummary, error) {
+func (m *MockAPI) DescribeServiceTasks(arg0 context.Context, arg1, arg2, arg3 string, arg4 bool) ([]compose.ContainerSummary, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DescribeServiceTasks", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "DescribeServiceTasks", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].([]compose.ContainerSummary)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DescribeServiceTasks indicates an expected call of DescribeServiceTasks
-func (mr *MockAPIMockRecorder) DescribeServiceTasks(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockAPIMockRecorder) DescribeServiceTasks(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeServiceTasks", reflect.TypeOf((*MockAPI)(nil).DescribeServiceTasks), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeServiceTasks", reflect.TypeOf((*MockAPI)(nil).DescribeServiceTasks), arg0, arg1, arg2, arg3, arg4)
 }
 
 // DescribeStackEvents mocks base method
@@ -256,6 +316,36 @@ func (mr *MockAPIMockRecorder) DescribeStackEvents(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeStackEvents", reflect.TypeOf((*MockAPI)(nil).DescribeStackEvents), arg0, arg1)
 }
 
+// DescribeStackEventsSince mocks base method
+func (m *MockAPI) DescribeStackEventsSince(arg0 context.Context, arg1, arg2 string) ([]*cloudformation.StackEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeStackEventsSince", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*cloudformation.StackEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeStackEventsSince indicates an expected call of DescribeStackEventsSince
+func (mr *MockAPIMockRecorder) DescribeStackEventsSince(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeStackEventsSince", reflect.TypeOf((*MockAPI)(nil).DescribeStackEventsSince), arg0, arg1, arg2)
+}
+
+// DetectStackDrift mocks base method
+func (m *MockAPI) DetectStackDrift(arg0 context.Context, arg1 string) ([]resourceDrift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetectStackDrift", arg0, arg1)
+	ret0, _ := ret[0].([]resourceDrift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DetectStackDrift indicates an expected call of DetectStackDrift
+func (mr *MockAPIMockRecorder) DetectStackDrift(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectStackDrift", reflect.TypeOf((*MockAPI)(nil).DetectStackDrift), arg0, arg1)
+}
+
 // GetDefaultVPC mocks base method
 func (m *MockAPI) GetDefaultVPC(arg0 context.Context) (string, error) {
 	m.ctrl.T.Helper()
@@ -271,6 +361,37 @@ func (mr *MockAPIMockRecorder) GetDefaultVPC(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultVPC", reflect.TypeOf((*MockAPI)(nil).GetDefaultVPC), arg0)
 }
 
+// GetDeploymentStatus mocks base method
+func (m *MockAPI) GetDeploymentStatus(arg0 context.Context, arg1 string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeploymentStatus", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDeploymentStatus indicates an expected call of GetDeploymentStatus
+func (mr *MockAPIMockRecorder) GetDeploymentStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeploymentStatus", reflect.TypeOf((*MockAPI)(nil).GetDeploymentStatus), arg0, arg1)
+}
+
+// GetImageDigest mocks base method
+func (m *MockAPI) GetImageDigest(arg0 context.Context, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageDigest", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImageDigest indicates an expected call of GetImageDigest
+func (mr *MockAPIMockRecorder) GetImageDigest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageDigest", reflect.TypeOf((*MockAPI)(nil).GetImageDigest), arg0, arg1, arg2)
+}
+
 // GetLoadBalancerURL mocks base method
 func (m *MockAPI) GetLoadBalancerURL(arg0 context.Context, arg1 string) (string, error) {
 	m.ctrl.T.Helper()
@@ -335,6 +456,21 @@ func (mr *MockAPIMockRecorder) GetPublicIPs(arg0 interface{}, arg1 ...interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublicIPs", reflect.TypeOf((*MockAPI)(nil).GetPublicIPs), varargs...)
 }
 
+// GetRegistryCredentials mocks base method
+func (m *MockAPI) GetRegistryCredentials(arg0 context.Context) (types.AuthConfig, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegistryCredentials", arg0)
+	ret0, _ := ret[0].(types.AuthConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegistryCredentials indicates an expected call of GetRegistryCredentials
+func (mr *MockAPIMockRecorder) GetRegistryCredentials(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistryCredentials", reflect.TypeOf((*MockAPI)(nil).GetRegistryCredentials), arg0)
+}
+
 // GetRoleArn mocks base method
 func (m *MockAPI) GetRoleArn(arg0 context.Context, arg1 string) (string, error) {
 	m.ctrl.T.Helper()
@@ -410,6 +546,21 @@ func (mr *MockAPIMockRecorder) GetStackID(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStackID", reflect.TypeOf((*MockAPI)(nil).GetStackID), arg0, arg1)
 }
 
+// GetStackTemplate mocks base method
+func (m *MockAPI) GetStackTemplate(arg0 context.Context, arg1 string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStackTemplate", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStackTemplate indicates an expected call of GetStackTemplate
+func (mr *MockAPIMockRecorder) GetStackTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStackTemplate", reflect.TypeOf((*MockAPI)(nil).GetStackTemplate), arg0, arg1)
+}
+
 // GetSubNets mocks base method
 func (m *MockAPI) GetSubNets(arg0 context.Context, arg1 string) ([]awsResource, error) {
 	m.ctrl.T.Helper()
@@ -440,6 +591,21 @@ func (mr *MockAPIMockRecorder) GetTaskStoppedReason(arg0, arg1, arg2 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskStoppedReason", reflect.TypeOf((*MockAPI)(nil).GetTaskStoppedReason), arg0, arg1, arg2)
 }
 
+// HasNATRoute mocks base method
+func (m *MockAPI) HasNATRoute(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasNATRoute", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasNATRoute indicates an expected call of HasNATRoute
+func (mr *MockAPIMockRecorder) HasNATRoute(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasNATRoute", reflect.TypeOf((*MockAPI)(nil).HasNATRoute), arg0, arg1)
+}
+
 // InspectSecret mocks base method
 func (m *MockAPI) InspectSecret(arg0 context.Context, arg1 string) (secrets.Secret, error) {
 	m.ctrl.T.Helper()
@@ -500,6 +666,21 @@ func (mr *MockAPIMockRecorder) ListSecrets(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*MockAPI)(nil).ListSecrets), arg0)
 }
 
+// ListStackOutputs mocks base method
+func (m *MockAPI) ListStackOutputs(arg0 context.Context, arg1 string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStackOutputs", arg0, arg1)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStackOutputs indicates an expected call of ListStackOutputs
+func (mr *MockAPIMockRecorder) ListStackOutputs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStackOutputs", reflect.TypeOf((*MockAPI)(nil).ListStackOutputs), arg0, arg1)
+}
+
 // ListStackParameters mocks base method
 func (m *MockAPI) ListStackParameters(arg0 context.Context, arg1 string) (map[string]string, error) {
 	m.ctrl.T.Helper()
@@ -623,6 +804,21 @@ func (mr *MockAPIMockRecorder) ResolveLoadBalancer(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveLoadBalancer", reflect.TypeOf((*MockAPI)(nil).ResolveLoadBalancer), arg0, arg1)
 }
 
+// ResolveRepository mocks base method
+func (m *MockAPI) ResolveRepository(arg0 context.Context, arg1 string, arg2 map[string]string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveRepository", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveRepository indicates an expected call of ResolveRepository
+func (mr *MockAPIMockRecorder) ResolveRepository(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRepository", reflect.TypeOf((*MockAPI)(nil).ResolveRepository), arg0, arg1, arg2)
+}
+
 // SecurityGroupExists mocks base method
 func (m *MockAPI) SecurityGroupExists(arg0 context.Context, arg1 string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,446 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	codedeployapi "github.com/aws/aws-sdk-go/service/codedeploy"
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/codedeploy"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/elasticloadbalancingv2"
+	"github.com/awslabs/goformation/v4/cloudformation/iam"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/sanathkr/go-yaml"
+)
+
+const (
+	deploymentRolling   = "rolling"
+	deploymentBlueGreen = "blue_green"
+
+	// codeDeployApplication is the CodeDeploy application shared by all blue/green services of the project
+	codeDeployApplication = "CodeDeployApplication"
+
+	// testPortOffset is added to published port to select test listener port when none is set
+	testPortOffset = 10000
+
+	// defaultTerminationWait is the delay, in minutes, original tasks are kept after traffic has been shifted
+	defaultTerminationWait = 5
+
+	// deploymentPollInterval is the delay between checks for CodeDeploy deployment status
+	deploymentPollInterval = 5 * time.Second
+)
+
+// trafficShiftingAliases maps short names to CodeDeploy predefined deployment configurations for ECS
+var trafficShiftingAliases = map[string]string{
+	"all_at_once": "CodeDeployDefault.ECSAllAtOnce",
+	"linear":      "CodeDeployDefault.ECSLinear10PercentEvery1Minutes",
+	"canary":      "CodeDeployDefault.ECSCanary10Percent5Minutes",
+}
+
+type deploymentConfig struct {
+	Type            string `json:"type,omitempty"`
+	TrafficShifting string `json:"traffic_shifting,omitempty"`
+	TestPort        uint32 `json:"test_port,omitempty"`
+	TerminationWait *int   `json:"termination_wait,omitempty"`
+}
+
+func deploymentGroupResourceName(service string) string {
+	return fmt.Sprintf("%sDeploymentGroup", normalizeResourceName(service))
+}
+
+func greenTargetGroupResourceName(service string, port types.ServicePortConfig) string {
+	return fmt.Sprintf("%sGreen", targetGroupResourceName(service, port))
+}
+
+// getBlueGreenConfig parses x-aws-deployment, set either as the deployment type or as a map with type and
+// traffic shifting options. It returns nil for services using the default ECS rolling updates.
+func getBlueGreenConfig(service types.ServiceConfig) (*deploymentConfig, error) {
+	v, ok := service.Extensions[extensionDeployment]
+	if !ok {
+		return nil, nil
+	}
+	var config deploymentConfig
+	if s, ok := v.(string); ok {
+		config.Type = s
+	} else {
+		marshalled, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(marshalled))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return nil, fmt.Errorf("service %s: invalid %s: %w", service.Name, extensionDeployment, err)
+		}
+	}
+	switch config.Type {
+	case "", deploymentRolling:
+		return nil, nil
+	case deploymentBlueGreen:
+	default:
+		return nil, fmt.Errorf("service %s: %s type must be either %q or %q", service.Name, extensionDeployment, deploymentRolling, deploymentBlueGreen)
+	}
+
+	if alias, ok := trafficShiftingAliases[config.TrafficShifting]; ok {
+		config.TrafficShifting = alias
+	}
+	if config.TrafficShifting == "" {
+		config.TrafficShifting = trafficShiftingAliases["all_at_once"]
+	}
+	if config.TerminationWait == nil {
+		wait := defaultTerminationWait
+		config.TerminationWait = &wait
+	}
+	if *config.TerminationWait < 0 || *config.TerminationWait > 2880 {
+		return nil, fmt.Errorf("service %s: %s termination_wait must be between 0 and 2880 minutes", service.Name, extensionDeployment)
+	}
+	return &config, nil
+}
+
+// getValidBlueGreenConfig returns service blue/green deployment configuration, once checked it can be applied
+func getValidBlueGreenConfig(project *types.Project, service types.ServiceConfig) (*deploymentConfig, error) {
+	config, err := getBlueGreenConfig(service)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	if err := config.validate(project, service); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// validate checks service can be deployed by CodeDeploy, which shifts traffic between two target groups for a
+// single load balancer listener
+func (c deploymentConfig) validate(project *types.Project, service types.ServiceConfig) error {
+	switch {
+	case isScheduled(service):
+		return fmt.Errorf("service %s: %s can't be used with %s", service.Name, extensionDeployment, extensionSchedule)
+	case useServiceConnect(project):
+		return fmt.Errorf("service %s: %s blue/green deployment can't be used with %s", service.Name, extensionDeployment, extensionServiceConnect)
+	case len(service.Ports) != 1:
+		return fmt.Errorf("service %s: %s blue/green deployment requires service to publish a single port", service.Name, extensionDeployment)
+	}
+	port := service.Ports[0]
+	if _, ok := service.Extensions[extensionLoadBalancerRule]; ok || isRoutedPort(project, port) {
+		return fmt.Errorf("service %s: %s blue/green deployment can't be used with %s", service.Name, extensionDeployment, extensionLoadBalancerRule)
+	}
+	testPort := c.testPort(port)
+	if testPort == port.Published || testPort > 65535 {
+		return fmt.Errorf("service %s: %s invalid test_port %d", service.Name, extensionDeployment, testPort)
+	}
+	for _, s := range project.Services {
+		for _, p := range s.Ports {
+			if p.Published == testPort {
+				return fmt.Errorf("service %s: %s test_port %d is already published by service %s", service.Name, extensionDeployment, testPort, s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (c deploymentConfig) testPort(port types.ServicePortConfig) uint32 {
+	if c.TestPort != 0 {
+		return c.TestPort
+	}
+	return port.Published + testPortOffset
+}
+
+// createBlueGreenDeployment declares the CodeDeploy deployment group in charge of service deployments. A second
+// "green" target group receives the replacement tasks, which can be reached on a test listener before production
+// traffic is shifted to them.
+func (b *ecsAPIService) createBlueGreenDeployment(project *types.Project, service types.ServiceConfig, config *deploymentConfig,
+	template *cloudformation.Template, resources awsResources) error {
+	port := service.Ports[0]
+	protocol := listenerProtocol(port, resources)
+	blue := targetGroupResourceName(service.Name, port)
+	green := greenTargetGroupResourceName(service.Name, port)
+	targetGroup, ok := template.Resources[blue].(*elasticloadbalancingv2.TargetGroup)
+	if !ok {
+		return fmt.Errorf("service %s: %s blue/green deployment requires port %d to be exposed by a load balancer target group",
+			service.Name, extensionDeployment, port.Published)
+	}
+	greenTargetGroup := *targetGroup
+	template.Resources[green] = &greenTargetGroup
+
+	testPort := port
+	testPort.Published = config.testPort(port)
+	for net := range service.Networks {
+		b.createIngress(service, net, testPort, template, resources)
+	}
+	testListener := b.createListener(service, testPort, template, green, resources.loadBalancer, protocol)
+
+	role := fmt.Sprintf("%sCodeDeployRole", normalizeResourceName(service.Name))
+	template.Resources[role] = &iam.Role{
+		AssumeRolePolicyDocument: codeDeployAssumeRolePolicyDocument,
+		ManagedPolicyArns:        []string{codeDeployECSPolicy},
+		Tags:                     serviceTags(project, service),
+	}
+
+	template.Resources[codeDeployApplication] = &codedeploy.Application{
+		ComputePlatform: codedeployapi.ComputePlatformEcs,
+	}
+
+	template.Resources[deploymentGroupResourceName(service.Name)] = &codedeploy.DeploymentGroup{
+		AWSCloudFormationDependsOn: []string{serviceResourceName(service.Name)},
+		AWSCloudFormationMetadata: map[string]interface{}{
+			extraProperties: map[string]interface{}{
+				"BlueGreenDeploymentConfiguration": map[string]interface{}{
+					"DeploymentReadyOption": map[string]interface{}{
+						"ActionOnTimeout": codedeployapi.DeploymentReadyActionContinueDeployment,
+					},
+					"TerminateBlueInstancesOnDeploymentSuccess": map[string]interface{}{
+						"Action":                       codedeployapi.InstanceActionTerminate,
+						"TerminationWaitTimeInMinutes": *config.TerminationWait,
+					},
+				},
+				"ECSServices": []interface{}{
+					map[string]interface{}{
+						"ClusterName": resources.cluster.ID(),
+						"ServiceName": cloudformation.GetAtt(serviceResourceName(service.Name), "Name"),
+					},
+				},
+				"LoadBalancerInfo": map[string]interface{}{
+					"TargetGroupPairInfoList": []interface{}{
+						map[string]interface{}{
+							"TargetGroups": []interface{}{
+								map[string]interface{}{"Name": cloudformation.GetAtt(blue, "TargetGroupName")},
+								map[string]interface{}{"Name": cloudformation.GetAtt(green, "TargetGroupName")},
+							},
+							"ProdTrafficRoute": map[string]interface{}{
+								"ListenerArns": []string{cloudformation.Ref(listenerResourceName(service.Name, port))},
+							},
+							"TestTrafficRoute": map[string]interface{}{
+								"ListenerArns": []string{cloudformation.Ref(testListener)},
+							},
+						},
+					},
+				},
+			},
+		},
+		ApplicationName: cloudformation.Ref(codeDeployApplication),
+		AutoRollbackConfiguration: &codedeploy.DeploymentGroup_AutoRollbackConfiguration{
+			Enabled: true,
+			Events: []string{
+				codedeployapi.AutoRollbackEventDeploymentFailure,
+				codedeployapi.AutoRollbackEventDeploymentStopOnRequest,
+			},
+		},
+		DeploymentConfigName: config.TrafficShifting,
+		DeploymentStyle: &codedeploy.DeploymentGroup_DeploymentStyle{
+			DeploymentOption: codedeployapi.DeploymentOptionWithTrafficControl,
+			DeploymentType:   codedeployapi.DeploymentTypeBlueGreen,
+		},
+		ServiceRoleArn: cloudformation.GetAtt(role, "Arn"),
+	}
+	return nil
+}
+
+func blueGreenServices(project *types.Project) []types.ServiceConfig {
+	var services []types.ServiceConfig
+	for _, service := range project.Services {
+		if config, err := getBlueGreenConfig(service); err == nil && config != nil {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// deployedTaskDefinitions returns the task definition ECS services created by the stack are running, indexed by
+// service logical ID
+func (b *ecsAPIService) deployedTaskDefinitions(ctx context.Context, stack string, resources stackResources) (map[string]string, error) {
+	cluster, svcArns, logicalIDs := resources.ecsServices()
+	if len(svcArns) == 0 {
+		return map[string]string{}, nil
+	}
+	if cluster == "" {
+		var err error
+		cluster, err = b.aws.GetStackClusterID(ctx, stack)
+		if err != nil {
+			return nil, err
+		}
+	}
+	services, err := b.aws.GetServiceTaskDefinition(ctx, cluster, svcArns)
+	if err != nil {
+		return nil, err
+	}
+	deployed := map[string]string{}
+	for arn, taskDefinition := range services {
+		deployed[logicalIDs[arn]] = taskDefinition
+	}
+	return deployed, nil
+}
+
+// pinBlueGreenTaskDefinitions keeps blue/green services of an existing stack on the task definition set by the last
+// applied template, as CloudFormation can't update services using the CodeDeploy deployment controller. The new task
+// definition is rolled out by a CodeDeploy deployment once stack has been updated. A service still referring to its
+// TaskDefinition resource is pinned to the revision this reference resolved to.
+func (b *ecsAPIService) pinBlueGreenTaskDefinitions(ctx context.Context, project *types.Project, template *cloudformation.Template) error {
+	services := blueGreenServices(project)
+	if len(services) == 0 {
+		return nil
+	}
+	exists, err := b.aws.StackExists(ctx, project.Name)
+	if err != nil || !exists {
+		return err
+	}
+	body, err := b.aws.GetStackTemplate(ctx, project.Name)
+	if err != nil {
+		return err
+	}
+	var applied struct {
+		Resources map[string]struct {
+			Properties map[string]interface{} `yaml:"Properties"`
+		} `yaml:"Resources"`
+	}
+	if err := yaml.Unmarshal(body, &applied); err != nil {
+		return err
+	}
+	resources, err := b.aws.ListStackResources(ctx, project.Name)
+	if err != nil {
+		return err
+	}
+	physicalIDs := map[string]string{}
+	for _, r := range resources {
+		physicalIDs[r.LogicalID] = r.ARN
+	}
+
+	for _, service := range services {
+		name := serviceResourceName(service.Name)
+		var taskDefinition string
+		switch v := applied.Resources[name].Properties["TaskDefinition"].(type) {
+		case string:
+			taskDefinition = v
+		case map[interface{}]interface{}:
+			if ref, ok := v["Ref"].(string); ok {
+				taskDefinition = physicalIDs[ref]
+			}
+		}
+		if taskDefinition == "" {
+			continue
+		}
+		if svc, ok := template.Resources[name].(*ecs.Service); ok {
+			svc.TaskDefinition = taskDefinition
+		}
+	}
+	return nil
+}
+
+// blueGreenAppSpec is the CodeDeploy application specification to replace service tasks by tasks running
+// taskDefinition, exposed through load balancer by container port
+func blueGreenAppSpec(taskDefinition string, container string, port uint32) (string, error) {
+	appSpec := map[string]interface{}{
+		"version": "0.0",
+		"Resources": []interface{}{
+			map[string]interface{}{
+				"TargetService": map[string]interface{}{
+					"Type": "AWS::ECS::Service",
+					"Properties": map[string]interface{}{
+						"TaskDefinition": taskDefinition,
+						"LoadBalancerInfo": map[string]interface{}{
+							"ContainerName": container,
+							"ContainerPort": port,
+						},
+					},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(appSpec)
+	return string(b), err
+}
+
+// deployBlueGreenServices creates a CodeDeploy deployment for blue/green services which task definition has been
+// updated by the stack, and waits for deployments to complete
+func (b *ecsAPIService) deployBlueGreenServices(ctx context.Context, project *types.Project) error {
+	services := blueGreenServices(project)
+	if len(services) == 0 {
+		return nil
+	}
+	resources, err := b.aws.ListStackResources(ctx, project.Name)
+	if err != nil {
+		return err
+	}
+	deployed, err := b.deployedTaskDefinitions(ctx, project.Name, resources)
+	if err != nil {
+		return err
+	}
+	physicalIDs := map[string]string{}
+	for _, r := range resources {
+		physicalIDs[r.LogicalID] = r.ARN
+	}
+
+	deployments := map[string]string{}
+	for _, service := range services {
+		taskDefinition := physicalIDs[fmt.Sprintf("%sTaskDefinition", normalizeResourceName(service.Name))]
+		if taskDefinition == "" || deployed[serviceResourceName(service.Name)] == taskDefinition {
+			continue
+		}
+		appSpec, err := blueGreenAppSpec(taskDefinition, service.Name, service.Ports[0].Target)
+		if err != nil {
+			return err
+		}
+		id, err := b.aws.CreateDeployment(ctx, physicalIDs[codeDeployApplication], physicalIDs[deploymentGroupResourceName(service.Name)], appSpec)
+		if err != nil {
+			return err
+		}
+		deployments[service.Name] = id
+	}
+	return b.waitDeployments(ctx, deployments)
+}
+
+// waitDeployments reports CodeDeploy deployments progress, indexed by service name, until they all complete
+func (b *ecsAPIService) waitDeployments(ctx context.Context, deployments map[string]string) error {
+	w := progress.ContextWriter(ctx)
+	statuses := map[string]string{}
+	for len(deployments) > 0 {
+		for service, id := range deployments {
+			status, info, err := b.aws.GetDeploymentStatus(ctx, id)
+			if err != nil {
+				return err
+			}
+			switch status {
+			case codedeployapi.DeploymentStatusSucceeded:
+				w.Event(progress.NewEvent(service, progress.Done, fmt.Sprintf("Deployment %s %s", id, status)))
+				delete(deployments, service)
+			case codedeployapi.DeploymentStatusFailed, codedeployapi.DeploymentStatusStopped:
+				w.Event(progress.NewEvent(service, progress.Error, fmt.Sprintf("Deployment %s %s %s", id, status, info)))
+				return fmt.Errorf("service %s: deployment %s %s: %s", service, id, status, info)
+			default:
+				if statuses[service] != status {
+					statuses[service] = status
+					w.Event(progress.NewEvent(service, progress.Working, fmt.Sprintf("Deployment %s %s", id, status)))
+				}
+			}
+		}
+		if len(deployments) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(deploymentPollInterval):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/codedeploy"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/elasticloadbalancingv2"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/golang/mock/gomock"
+	"github.com/sanathkr/go-yaml"
+	"gotest.tools/v3/assert"
+)
+
+func TestBlueGreenDeployment(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-deployment:
+      type: blue_green
+      traffic_shifting: canary
+  worker:
+    image: worker
+`, nil, useDefaultVPC)
+	web := template.Resources["WebService"].(*ecs.Service)
+	assert.Equal(t, web.DeploymentController.Type, "CODE_DEPLOY")
+	assert.Check(t, web.DeploymentConfiguration.DeploymentCircuitBreaker == nil)
+	worker := template.Resources["WorkerService"].(*ecs.Service)
+	assert.Equal(t, worker.DeploymentController.Type, "ECS")
+	assert.Check(t, template.Resources["WorkerDeploymentGroup"] == nil)
+
+	assert.Check(t, template.Resources["WebTCP80TargetGroupGreen"] != nil)
+	listener := template.Resources["WebTCP10080Listener"].(*elasticloadbalancingv2.Listener)
+	assert.Equal(t, listener.Port, 10080)
+	assert.Equal(t, listener.DefaultActions[0].ForwardConfig.TargetGroups[0].TargetGroupArn, cloudformation.Ref("WebTCP80TargetGroupGreen"))
+	assert.Check(t, template.Resources["Default10080Ingress"] != nil)
+
+	group := template.Resources["WebDeploymentGroup"].(*codedeploy.DeploymentGroup)
+	assert.Equal(t, group.DeploymentConfigName, "CodeDeployDefault.ECSCanary10Percent5Minutes")
+	assert.Equal(t, group.DeploymentStyle.DeploymentType, "BLUE_GREEN")
+	assert.Equal(t, group.ApplicationName, cloudformation.Ref("CodeDeployApplication"))
+
+	bytes, err := marshall(template, "yaml")
+	assert.NilError(t, err)
+	var marshalled struct {
+		Resources map[string]struct {
+			Metadata   map[string]interface{} `yaml:"Metadata"`
+			Properties struct {
+				BlueGreenDeploymentConfiguration struct {
+					TerminateBlueInstancesOnDeploymentSuccess struct {
+						TerminationWaitTimeInMinutes int `yaml:"TerminationWaitTimeInMinutes"`
+					} `yaml:"TerminateBlueInstancesOnDeploymentSuccess"`
+				} `yaml:"BlueGreenDeploymentConfiguration"`
+				ECSServices      []map[string]interface{} `yaml:"ECSServices"`
+				LoadBalancerInfo struct {
+					TargetGroupPairInfoList []struct {
+						TargetGroups     []map[string]interface{} `yaml:"TargetGroups"`
+						ProdTrafficRoute struct {
+							ListenerArns []map[string]string `yaml:"ListenerArns"`
+						} `yaml:"ProdTrafficRoute"`
+						TestTrafficRoute struct {
+							ListenerArns []map[string]string `yaml:"ListenerArns"`
+						} `yaml:"TestTrafficRoute"`
+					} `yaml:"TargetGroupPairInfoList"`
+				} `yaml:"LoadBalancerInfo"`
+			} `yaml:"Properties"`
+		} `yaml:"Resources"`
+	}
+	assert.NilError(t, yaml.Unmarshal(bytes, &marshalled))
+	properties := marshalled.Resources["WebDeploymentGroup"].Properties
+	assert.Check(t, marshalled.Resources["WebDeploymentGroup"].Metadata == nil)
+	assert.Equal(t, properties.BlueGreenDeploymentConfiguration.TerminateBlueInstancesOnDeploymentSuccess.TerminationWaitTimeInMinutes, 5)
+	assert.Equal(t, len(properties.ECSServices), 1)
+	pairs := properties.LoadBalancerInfo.TargetGroupPairInfoList
+	assert.Equal(t, len(pairs), 1)
+	assert.Equal(t, len(pairs[0].TargetGroups), 2)
+	assert.Equal(t, pairs[0].ProdTrafficRoute.ListenerArns[0]["Ref"], "WebTCP80Listener")
+	assert.Equal(t, pairs[0].TestTrafficRoute.ListenerArns[0]["Ref"], "WebTCP10080Listener")
+}
+
+func TestBlueGreenDeploymentShortSyntax(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-deployment: blue_green
+`, nil, useDefaultVPC)
+	group := template.Resources["WebDeploymentGroup"].(*codedeploy.DeploymentGroup)
+	assert.Equal(t, group.DeploymentConfigName, "CodeDeployDefault.ECSAllAtOnce")
+}
+
+func TestBlueGreenDeploymentValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{
+			name: "no port",
+			yaml: `
+services:
+  web:
+    image: nginx
+    x-aws-deployment: blue_green
+`,
+			err: "service web: x-aws-deployment blue/green deployment requires service to publish a single port",
+		},
+		{
+			name: "unknown type",
+			yaml: `
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-deployment: canary
+`,
+			err: `service web: x-aws-deployment type must be either "rolling" or "blue_green"`,
+		},
+		{
+			name: "test port conflict",
+			yaml: `
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-deployment:
+      type: blue_green
+      test_port: 8080
+  api:
+    image: api
+    ports:
+      - 8080:8080
+`,
+			err: "service web: x-aws-deployment test_port 8080 is already published by service api",
+		},
+		{
+			name: "service connect",
+			yaml: `
+x-aws-service_connect: true
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-deployment: blue_green
+`,
+			err: "service web: x-aws-deployment blue/green deployment can't be used with x-aws-service_connect",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convertYaml(t, tt.yaml, errors.New(tt.err), useDefaultVPC)
+		})
+	}
+}
+
+func TestPinBlueGreenTaskDefinitions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	backend := &ecsAPIService{aws: m}
+	compose := func(digest string) *types.Project {
+		return loadConfig(t, `
+services:
+  web:
+    image: nginx@sha256:`+digest+`
+    ports:
+      - 80:80
+    x-aws-deployment: blue_green
+`)
+	}
+	render := func(project *types.Project, applied []byte, taskDefinition string) []byte {
+		useDefaultVPC(m.EXPECT())
+		m.EXPECT().StackExists(gomock.Any(), t.Name()).Return(applied != nil, nil)
+		if applied != nil {
+			m.EXPECT().GetStackTemplate(gomock.Any(), t.Name()).Return(applied, nil)
+			m.EXPECT().ListStackResources(gomock.Any(), t.Name()).Return(stackResources{
+				{LogicalID: "WebService", Type: "AWS::ECS::Service", ARN: "web"},
+				{LogicalID: "WebTaskDefinition", Type: "AWS::ECS::TaskDefinition", ARN: taskDefinition},
+			}, nil)
+		}
+		rendered, err := backend.render(context.TODO(), project, "yaml")
+		assert.NilError(t, err)
+		return rendered
+	}
+	serviceTaskDefinition := func(rendered []byte) interface{} {
+		var template struct {
+			Resources map[string]struct {
+				UpdateReplacePolicy string                 `yaml:"UpdateReplacePolicy"`
+				Properties          map[string]interface{} `yaml:"Properties"`
+			} `yaml:"Resources"`
+		}
+		assert.NilError(t, yaml.Unmarshal(rendered, &template))
+		assert.Equal(t, template.Resources["WebTaskDefinition"].UpdateReplacePolicy, "Retain")
+		return template.Resources["WebService"].Properties["TaskDefinition"]
+	}
+
+	created := render(compose("1111111111111111111111111111111111111111111111111111111111111111"), nil, "")
+	assert.DeepEqual(t, serviceTaskDefinition(created), map[interface{}]interface{}{"Ref": "WebTaskDefinition"})
+
+	// first update pins service to the revision its TaskDefinition reference resolved to
+	first := render(compose("2222222222222222222222222222222222222222222222222222222222222222"), created, "web:1")
+	assert.Equal(t, serviceTaskDefinition(first), "web:1")
+
+	// once CodeDeploy deployed web:2, second update keeps service unchanged
+	second := render(compose("3333333333333333333333333333333333333333333333333333333333333333"), first, "web:2")
+	assert.Equal(t, serviceTaskDefinition(second), "web:1")
+	changes, err := compareTemplates(first, second)
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 1)
+	assert.Equal(t, changes[0].LogicalID, "WebTaskDefinition")
+}
+
+func TestDeployBlueGreenServices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	m.EXPECT().ListStackResources(gomock.Any(), t.Name()).Return(stackResources{
+		{LogicalID: "Cluster", Type: "AWS::ECS::Cluster", ARN: "cluster"},
+		{LogicalID: "WebService", Type: "AWS::ECS::Service", ARN: "web"},
+		{LogicalID: "WebTaskDefinition", Type: "AWS::ECS::TaskDefinition", ARN: "web:2"},
+		{LogicalID: "CodeDeployApplication", Type: "AWS::CodeDeploy::Application", ARN: "app"},
+		{LogicalID: "WebDeploymentGroup", Type: "AWS::CodeDeploy::DeploymentGroup", ARN: "web-group"},
+	}, nil)
+	m.EXPECT().GetServiceTaskDefinition(gomock.Any(), "cluster", []string{"web"}).Return(map[string]string{"web": "web:1"}, nil)
+	appSpec, err := blueGreenAppSpec("web:2", "web", 80)
+	assert.NilError(t, err)
+	m.EXPECT().CreateDeployment(gomock.Any(), "app", "web-group", appSpec).Return("d-123", nil)
+	m.EXPECT().GetDeploymentStatus(gomock.Any(), "d-123").Return("Succeeded", "", nil)
+
+	project := loadConfig(t, `
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-deployment: blue_green
+`)
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+	backend := &ecsAPIService{aws: m}
+	assert.NilError(t, backend.deployBlueGreenServices(ctx, project))
+	assert.Equal(t, len(w.events), 1)
+	assert.Equal(t, w.events[0].ID, "web")
+	assert.Equal(t, w.events[0].Status, progress.Done)
+}
+
+func TestBlueGreenAppSpec(t *testing.T) {
+	appSpec, err := blueGreenAppSpec("arn:aws:ecs:us-east-1:123456789012:task-definition/web:2", "web", 80)
+	assert.NilError(t, err)
+	assert.Equal(t, appSpec, `{"Resources":[{"TargetService":{"Properties":{"LoadBalancerInfo":{"ContainerName":"web","ContainerPort":80},`+
+		`"TaskDefinition":"arn:aws:ecs:us-east-1:123456789012:task-definition/web:2"},"Type":"AWS::ECS::Service"}}],"version":"0.0"}`)
+}
+
+func TestBlueGreenDeploymentRequiresTargetGroup(t *testing.T) {
+	project := loadConfig(t, `
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-deployment: blue_green
+`)
+	config, err := getBlueGreenConfig(project.Services[0])
+	assert.NilError(t, err)
+	backend := &ecsAPIService{}
+	err = backend.createBlueGreenDeployment(project, project.Services[0], config, cloudformation.NewTemplate(), awsResources{})
+	assert.Error(t, err, "service web: x-aws-deployment blue/green deployment requires port 80 to be exposed by a load balancer target group")
+}
@@ -0,0 +1,97 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/compose-spec/compose-go/types"
+)
+
+const (
+	fargateCapacityProvider     = "FARGATE"
+	fargateSpotCapacityProvider = "FARGATE_SPOT"
+)
+
+// capacityConfig distributes service tasks between Fargate Spot and on-demand Fargate capacity providers. Base
+// is the minimum number of tasks to run on-demand, remaining tasks being distributed according to weights
+type capacityConfig struct {
+	Spot     int `json:"spot,omitempty"`
+	OnDemand int `json:"ondemand,omitempty"`
+	Base     int `json:"base,omitempty"`
+}
+
+func getCapacityConfig(project *types.Project, service types.ServiceConfig) (*capacityConfig, error) {
+	v, ok := service.Extensions[extensionCapacity]
+	if !ok {
+		return nil, nil
+	}
+	marshalled, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var config capacityConfig
+	err = json.Unmarshal(marshalled, &config)
+	if err != nil {
+		return nil, fmt.Errorf("service %s has an invalid %s: %w", service.Name, extensionCapacity, err)
+	}
+	if config.Spot < 0 || config.OnDemand < 0 || config.Base < 0 {
+		return nil, fmt.Errorf("service %s: %s values can't be negative", service.Name, extensionCapacity)
+	}
+	if config.Spot == 0 && config.OnDemand == 0 {
+		return nil, fmt.Errorf("service %s: %s MUST define spot or ondemand weight", service.Name, extensionCapacity)
+	}
+	if requireEC2(service) {
+		return nil, fmt.Errorf("service %s: %s can't be used by services running on EC2", service.Name, extensionCapacity)
+	}
+	// Fargate capacity providers are only attached to the cluster created by compose
+	if _, ok := project.Extensions[extensionCluster]; ok {
+		return nil, fmt.Errorf("service %s: %s requires the cluster to be created by compose, can't be used with %s", service.Name, extensionCapacity, extensionCluster)
+	}
+	return &config, nil
+}
+
+func (c capacityConfig) strategy() []ecs.Service_CapacityProviderStrategyItem {
+	var strategy []ecs.Service_CapacityProviderStrategyItem
+	if c.OnDemand > 0 || c.Base > 0 {
+		strategy = append(strategy, ecs.Service_CapacityProviderStrategyItem{
+			Base:             c.Base,
+			CapacityProvider: fargateCapacityProvider,
+			Weight:           c.OnDemand,
+		})
+	}
+	if c.Spot > 0 {
+		strategy = append(strategy, ecs.Service_CapacityProviderStrategyItem{
+			CapacityProvider: fargateSpotCapacityProvider,
+			Weight:           c.Spot,
+		})
+	}
+	return strategy
+}
+
+// requireFargateCapacityProviders checks if any service relies on Fargate capacity providers, which then have to
+// be attached to the cluster
+func requireFargateCapacityProviders(project *types.Project) bool {
+	for _, service := range project.Services {
+		if _, ok := service.Extensions[extensionCapacity]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestFargateSpotCapacity(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  worker:
+    image: worker
+    x-aws-capacity:
+      spot: 3
+      ondemand: 1
+      base: 1
+  batch:
+    image: batch
+    x-aws-capacity:
+      spot: 1
+`, nil, useDefaultVPC)
+	cluster := template.Resources["Cluster"].(*ecs.Cluster)
+	assert.DeepEqual(t, cluster.CapacityProviders, []string{"FARGATE", "FARGATE_SPOT"})
+
+	worker := template.Resources["WorkerService"].(*ecs.Service)
+	assert.Equal(t, worker.LaunchType, "")
+	assert.DeepEqual(t, worker.CapacityProviderStrategy, []ecs.Service_CapacityProviderStrategyItem{
+		{CapacityProvider: "FARGATE", Base: 1, Weight: 1},
+		{CapacityProvider: "FARGATE_SPOT", Weight: 3},
+	})
+
+	batch := template.Resources["BatchService"].(*ecs.Service)
+	assert.DeepEqual(t, batch.CapacityProviderStrategy, []ecs.Service_CapacityProviderStrategyItem{
+		{CapacityProvider: "FARGATE_SPOT", Weight: 1},
+	})
+}
+
+func TestDefaultClusterWithoutCapacityProviders(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  api:
+    image: api
+`, nil, useDefaultVPC)
+	cluster := template.Resources["Cluster"].(*ecs.Cluster)
+	assert.Check(t, cluster.CapacityProviders == nil)
+	api := template.Resources["ApiService"].(*ecs.Service)
+	assert.Equal(t, api.LaunchType, "FARGATE")
+}
+
+func TestFargateSpotCapacityInvalid(t *testing.T) {
+	convertYaml(t, `
+services:
+  worker:
+    image: worker
+    x-aws-capacity:
+      base: 1
+`, errors.New("service worker: x-aws-capacity MUST define spot or ondemand weight"), useDefaultVPC)
+}
+
+func TestFargateSpotCapacityExternalCluster(t *testing.T) {
+	convertYaml(t, `
+x-aws-cluster: "arn:aws:ecs:region:account:cluster/name"
+services:
+  worker:
+    image: worker
+    x-aws-capacity:
+      spot: 1
+`, errors.New("service worker: x-aws-capacity requires the cluster to be created by compose, can't be used with x-aws-cluster"),
+		useDefaultVPC, func(m *MockAPIMockRecorder) {
+			m.ResolveCluster(gomock.Any(), "arn:aws:ecs:region:account:cluster/name").Return(existingAWSResource{
+				arn: "arn:aws:ecs:region:account:cluster/name",
+				id:  "name",
+			}, nil)
+		})
+}
@@ -40,8 +40,6 @@ import (
 	cliconfig "github.com/docker/cli/cli/config"
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/opencontainers/go-digest"
-	"sigs.k8s.io/kustomize/kyaml/yaml"
-	"sigs.k8s.io/kustomize/kyaml/yaml/merge2"
 
 	"github.com/docker/compose-cli/api/config"
 	"github.com/docker/compose-cli/utils"
@@ -51,55 +49,49 @@ func (b *ecsAPIService) Convert(ctx context.Context, project *types.Project, opt
 	if err := checkUnsupportedConvertOptions(ctx, options); err != nil {
 		return nil, err
 	}
-	err := b.resolveServiceImagesDigests(ctx, project)
-	if err != nil {
-		return nil, err
+	if isDiff(project) {
+		return b.diff(ctx, project)
 	}
+	if isCostEstimate(project) {
+		return b.estimateCost(ctx, project)
+	}
+	return b.render(ctx, project, options.Format)
+}
 
-	template, err := b.convert(ctx, project)
+// render converts the compose model into a CloudFormation template, applying x-aws-cloudformation overlays
+func (b *ecsAPIService) render(ctx context.Context, project *types.Project, format string) ([]byte, error) {
+	err := b.resolveServiceImagesDigests(ctx, project)
 	if err != nil {
 		return nil, err
 	}
 
-	bytes, err := marshall(template, options.Format)
+	err = b.resolveECRImageNames(ctx, project)
 	if err != nil {
 		return nil, err
 	}
 
-	x, ok := project.Extensions[extensionCloudFormation]
-	if !ok {
-		return bytes, nil
-	}
-	if options.Format != "yaml" {
-		return nil, fmt.Errorf("format %q with overlays is not supported", options.Format)
-	}
-
-	nodes, err := yaml.Parse(string(bytes))
+	template, err := b.convert(ctx, project)
 	if err != nil {
 		return nil, err
 	}
 
-	bytes, err = yaml.Marshal(x)
+	err = b.pinBlueGreenTaskDefinitions(ctx, project, template)
 	if err != nil {
 		return nil, err
 	}
-	overlay, err := yaml.Parse(string(bytes))
-	if err != nil {
-		return nil, err
+
+	x, ok := project.Extensions[extensionCloudFormation]
+	if !ok {
+		return marshall(template, format)
 	}
-	nodes, err = merge2.Merge(overlay, nodes, yaml.MergeOptions{
-		ListIncreaseDirection: yaml.MergeOptionsListPrepend,
-	})
-	if err != nil {
+	if err := checkOverlayResources(template, x); err != nil {
 		return nil, err
 	}
-
-	s, err := nodes.String()
+	bytes, err := marshall(template, "yaml")
 	if err != nil {
 		return nil, err
 	}
-	bytes = []byte(s)
-	return bytes, err
+	return applyOverlay(bytes, x, format)
 }
 
 func checkUnsupportedConvertOptions(ctx context.Context, o api.ConvertOptions) error {
@@ -112,11 +104,35 @@ func (b *ecsAPIService) resolveServiceImagesDigests(ctx context.Context, project
 		return err
 	}
 
+	// images pushed to ECR are pinned once pushed, and might not exist yet
+	resolved := *project
+	resolved.Services = nil
+	for _, service := range project.Services {
+		repository, err := getECRRepository(project, service)
+		if err != nil {
+			return err
+		}
+		if repository == "" {
+			resolved.Services = append(resolved.Services, service)
+		}
+	}
+
 	resolver := remotes.CreateResolver(configFile)
-	return project.ResolveImages(func(named reference.Named) (digest.Digest, error) {
+	err = resolved.ResolveImages(func(named reference.Named) (digest.Digest, error) {
 		_, desc, err := resolver.Resolve(ctx, named.String())
 		return desc.Digest, err
 	})
+	if err != nil {
+		return err
+	}
+	for _, service := range resolved.Services {
+		for i := range project.Services {
+			if project.Services[i].Name == service.Name {
+				project.Services[i].Image = service.Image
+			}
+		}
+	}
+	return nil
 }
 
 func (b *ecsAPIService) convert(ctx context.Context, project *types.Project) (*cloudformation.Template, error) {
@@ -143,6 +159,13 @@ func (b *ecsAPIService) convert(ctx context.Context, project *types.Project) (*c
 		}
 	}
 
+	for name, config := range project.Configs {
+		err := b.createConfig(project, name, config, template)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	b.createLogGroup(project, template)
 
 	// Private DNS namespace will allow DNS name for the services to be <service>.<project>.local
@@ -150,7 +173,10 @@ func (b *ecsAPIService) convert(ctx context.Context, project *types.Project) (*c
 
 	b.createNFSMountTarget(project, resources, template)
 
-	b.createAccessPoints(project, resources, template)
+	err = b.createAccessPoints(project, resources, template)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, service := range project.Services {
 		err := b.createService(project, service, template, resources)
@@ -164,11 +190,12 @@ func (b *ecsAPIService) convert(ctx context.Context, project *types.Project) (*c
 		}
 	}
 
-	err = b.createCapacityProvider(ctx, project, template, resources)
+	err = b.createCapacityProviders(ctx, project, template, resources)
 	if err != nil {
 		return nil, err
 	}
 
+	b.createOutputs(project, resources, template)
 	return template, nil
 }
 
@@ -191,73 +218,79 @@ func (b *ecsAPIService) createService(project *types.Project, service types.Serv
 	taskDefinition := fmt.Sprintf("%sTaskDefinition", normalizeResourceName(service.Name))
 	template.Resources[taskDefinition] = definition
 
-	var healthCheck *cloudmap.Service_HealthCheckConfig
-	serviceRegistry := b.createServiceRegistry(service, template, healthCheck)
+	if isScheduled(service) {
+		return b.createScheduledTask(project, service, template, resources, taskDefinition, taskExecutionRole, taskRole)
+	}
+
+	blueGreen, err := getValidBlueGreenConfig(project, service)
+	if err != nil {
+		return err
+	}
 
 	var (
-		dependsOn []string
-		serviceLB []ecs.Service_LoadBalancer
+		serviceRegistries []ecs.Service_ServiceRegistry
+		metadata          map[string]interface{}
 	)
-	for _, port := range service.Ports {
-		for net := range service.Networks {
-			b.createIngress(service, net, port, template, resources)
-		}
+	if useServiceConnect(project) {
+		metadata = serviceConnectConfiguration(service, getLogConfiguration(service, project))
+	} else {
+		healthCheck := toServiceRegistryHealthCheck(service)
+		serviceRegistries = append(serviceRegistries, b.createServiceRegistry(service, template, healthCheck))
+	}
 
-		protocol := strings.ToUpper(port.Protocol)
-		if resources.loadBalancerType == elbv2.LoadBalancerTypeEnumApplication {
-			// we don't set Https as a certificate must be specified for HTTPS listeners
-			protocol = elbv2.ProtocolEnumHttp
-		}
-		targetGroupName := b.createTargetGroup(project, service, port, template, protocol, resources.vpc)
-		listenerName := b.createListener(service, port, template, targetGroupName, resources.loadBalancer, protocol)
-		dependsOn = append(dependsOn, listenerName)
-		serviceLB = append(serviceLB, ecs.Service_LoadBalancer{
-			ContainerName:  service.Name,
-			ContainerPort:  int(port.Target),
-			TargetGroupArn: cloudformation.Ref(targetGroupName),
-		})
+	dependsOn, serviceLB, err := b.createLoadBalancerTargets(project, service, template, resources)
+	if err != nil {
+		return err
 	}
 
-	desiredCount := 1
-	if service.Deploy != nil && service.Deploy.Replicas != nil {
-		desiredCount = int(*service.Deploy.Replicas)
+	desiredCount := getDesiredCount(service)
+	dependsOn = append(dependsOn, b.serviceDependencies(project, service, resources)...)
+
+	minPercent, maxPercent, err := computeRollingUpdateLimits(service)
+	if err != nil {
+		return err
 	}
 
-	for dependency := range service.DependsOn {
-		dependsOn = append(dependsOn, serviceResourceName(dependency))
+	circuitBreaker, err := computeDeploymentCircuitBreaker(service)
+	if err != nil {
+		return err
 	}
 
-	for _, s := range service.Volumes {
-		dependsOn = append(dependsOn, b.mountTargets(s.Source, resources)...)
+	deploymentController := ecsapi.DeploymentControllerTypeEcs
+	if blueGreen != nil {
+		deploymentController = ecsapi.DeploymentControllerTypeCodeDeploy
+		circuitBreaker = nil // not supported by CodeDeploy deployment controller
+		// service keeps running the previous revision until CodeDeploy replaces its tasks
+		definition.AWSCloudFormationUpdateReplacePolicy = "Retain"
+		if err := b.createBlueGreenDeployment(project, service, blueGreen, template, resources); err != nil {
+			return err
+		}
 	}
 
-	minPercent, maxPercent, err := computeRollingUpdateLimits(service)
+	launchType, platformVersion, assignPublicIP := getLaunchParameters(service, resources)
+	capacityProviderStrategy, err := getCapacityProviderStrategy(project, service)
 	if err != nil {
 		return err
 	}
-
-	assignPublicIP := ecsapi.AssignPublicIpEnabled
-	launchType := ecsapi.LaunchTypeFargate
-	platformVersion := "1.4.0" // LATEST which is set to 1.3.0 (?) which doesn’t allow efs volumes.
-	if requireEC2(service) {
-		assignPublicIP = ecsapi.AssignPublicIpDisabled
-		launchType = ecsapi.LaunchTypeEc2
-		platformVersion = "" // The platform version must be null when specifying an EC2 launch type
+	if capacityProviderStrategy != nil {
+		launchType = "" // launch type and capacity provider strategy are mutually exclusive
 	}
 
 	template.Resources[serviceResourceName(service.Name)] = &ecs.Service{
 		AWSCloudFormationDependsOn: dependsOn,
+		AWSCloudFormationMetadata:  metadata,
+		CapacityProviderStrategy:   capacityProviderStrategy,
 		Cluster:                    resources.cluster.ARN(),
 		DesiredCount:               desiredCount,
 		DeploymentController: &ecs.Service_DeploymentController{
-			Type: ecsapi.DeploymentControllerTypeEcs,
+			Type: deploymentController,
 		},
 		DeploymentConfiguration: &ecs.Service_DeploymentConfiguration{
-			MaximumPercent:        maxPercent,
-			MinimumHealthyPercent: minPercent,
+			DeploymentCircuitBreaker: circuitBreaker,
+			MaximumPercent:           maxPercent,
+			MinimumHealthyPercent:    minPercent,
 		},
-		LaunchType: launchType,
-		// TODO we miss support for https://github.com/aws/containers-roadmap/issues/631 to select a capacity provider
+		LaunchType:    launchType,
 		LoadBalancers: serviceLB,
 		NetworkConfiguration: &ecs.Service_NetworkConfiguration{
 			AwsvpcConfiguration: &ecs.Service_AwsVpcConfiguration{
@@ -269,32 +302,164 @@ func (b *ecsAPIService) createService(project *types.Project, service types.Serv
 		PlatformVersion:    platformVersion,
 		PropagateTags:      ecsapi.PropagateTagsService,
 		SchedulingStrategy: ecsapi.SchedulingStrategyReplica,
-		ServiceRegistries:  []ecs.Service_ServiceRegistry{serviceRegistry},
+		ServiceRegistries:  serviceRegistries,
 		Tags:               serviceTags(project, service),
 		TaskDefinition:     cloudformation.Ref(normalizeResourceName(taskDefinition)),
 	}
 	return nil
 }
 
+func getDesiredCount(service types.ServiceConfig) int {
+	if service.Deploy != nil && service.Deploy.Replicas != nil {
+		return int(*service.Deploy.Replicas)
+	}
+	return 1
+}
+
+// getLaunchParameters returns launch type, platform version and public IP assignment for service tasks
+func getLaunchParameters(service types.ServiceConfig, resources awsResources) (string, string, string) {
+	if requireEC2(service) {
+		// The platform version must be null when specifying an EC2 launch type
+		return ecsapi.LaunchTypeEc2, "", ecsapi.AssignPublicIpDisabled
+	}
+	assignPublicIP := ecsapi.AssignPublicIpEnabled
+	if resources.disablePublicIP {
+		assignPublicIP = ecsapi.AssignPublicIpDisabled
+	}
+	// LATEST which is set to 1.3.0 (?) which doesn’t allow efs volumes.
+	return ecsapi.LaunchTypeFargate, "1.4.0", assignPublicIP
+}
+
+// getCapacityProviderStrategy binds services running on EC2 to the capacity provider matching their machine requirements,
+// and Fargate services to Fargate Spot and on-demand capacity providers according to x-aws-capacity
+func getCapacityProviderStrategy(project *types.Project, service types.ServiceConfig) ([]ecs.Service_CapacityProviderStrategyItem, error) {
+	capacity, err := getCapacityConfig(project, service)
+	if err != nil {
+		return nil, err
+	}
+	if capacity != nil {
+		return capacity.strategy(), nil
+	}
+	if !requireEC2(service) {
+		return nil, nil
+	}
+	provider, err := capacityProviderResourceName(service)
+	if err != nil {
+		return nil, err
+	}
+	return []ecs.Service_CapacityProviderStrategyItem{
+		{
+			CapacityProvider: cloudformation.Ref(fmt.Sprintf("%sCapacityProvider", provider)),
+			Weight:           1,
+		},
+	}, nil
+}
+
+// serviceDependencies lists resources service depends on, according to depends_on and volumes mount targets
+func (b *ecsAPIService) serviceDependencies(project *types.Project, service types.ServiceConfig, resources awsResources) []string {
+	var dependsOn []string
+	for dependency := range service.DependsOn {
+		if s, err := project.GetService(dependency); err == nil && isScheduled(s) {
+			dependsOn = append(dependsOn, scheduleRuleResourceName(dependency))
+			continue
+		}
+		dependsOn = append(dependsOn, serviceResourceName(dependency))
+	}
+
+	for _, s := range service.Volumes {
+		if s.Type != types.VolumeTypeVolume {
+			continue
+		}
+		dependsOn = append(dependsOn, b.mountTargets(s.Source, resources)...)
+	}
+	return dependsOn
+}
+
+func (b *ecsAPIService) createLoadBalancerTargets(project *types.Project, service types.ServiceConfig, template *cloudformation.Template, resources awsResources) ([]string, []ecs.Service_LoadBalancer, error) {
+	rules, err := getLoadBalancerRules(project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		dependsOn []string
+		serviceLB []ecs.Service_LoadBalancer
+	)
+	for _, port := range service.Ports {
+		for net := range service.Networks {
+			b.createIngress(service, net, port, template, resources)
+		}
+
+		protocol := listenerProtocol(port, resources)
+		targetGroupName, err := b.createTargetGroup(project, service, port, template, protocol, resources.vpc)
+		if err != nil {
+			return nil, nil, err
+		}
+		rule, routed := rules[service.Name]
+		if routed && resources.loadBalancerType != elbv2.LoadBalancerTypeEnumApplication {
+			return nil, nil, fmt.Errorf("service %s: %s requires an Application Load Balancer", service.Name, extensionLoadBalancerRule)
+		}
+		if routed || isRoutedPort(project, port) {
+			listenerName, err := b.createSharedListener(service, port, template, targetGroupName, resources.loadBalancer, protocol, routed)
+			if err != nil {
+				return nil, nil, err
+			}
+			dependsOn = append(dependsOn, listenerName)
+			if routed {
+				ruleName := b.createListenerRule(service, port, template, listenerName, targetGroupName, rule)
+				dependsOn = append(dependsOn, ruleName)
+			}
+		} else {
+			listenerName := b.createListener(service, port, template, targetGroupName, resources.loadBalancer, protocol)
+			dependsOn = append(dependsOn, listenerName)
+		}
+		serviceLB = append(serviceLB, ecs.Service_LoadBalancer{
+			ContainerName:  service.Name,
+			ContainerPort:  int(port.Target),
+			TargetGroupArn: cloudformation.Ref(targetGroupName),
+		})
+	}
+	return dependsOn, serviceLB, nil
+}
+
+func listenerProtocol(port types.ServicePortConfig, resources awsResources) string {
+	if resources.loadBalancerType == elbv2.LoadBalancerTypeEnumApplication {
+		// we don't set Https as a certificate must be specified for HTTPS listeners
+		return elbv2.ProtocolEnumHttp
+	}
+	return strings.ToUpper(port.Protocol)
+}
+
 const allProtocols = "-1"
 
+// createIngress opens container port on network security group, as well as published port as load balancer listener
+// port when distinct
 func (b *ecsAPIService) createIngress(service types.ServiceConfig, net string, port types.ServicePortConfig, template *cloudformation.Template, resources awsResources) {
 	protocol := strings.ToUpper(port.Protocol)
 	if protocol == "" {
 		protocol = allProtocols
 	}
-	ingress := fmt.Sprintf("%s%dIngress", normalizeResourceName(net), port.Target)
-	template.Resources[ingress] = &ec2.SecurityGroupIngress{
-		CidrIp:      "0.0.0.0/0",
-		Description: fmt.Sprintf("%s:%d/%s on %s network", service.Name, port.Target, port.Protocol, net),
-		GroupId:     resources.securityGroups[net],
-		FromPort:    int(port.Target),
-		IpProtocol:  protocol,
-		ToPort:      int(port.Target),
+	ports := []uint32{port.Target}
+	if port.Published != port.Target {
+		ports = append(ports, port.Published)
+	}
+	for _, p := range ports {
+		ingress := fmt.Sprintf("%s%dIngress", normalizeResourceName(net), p)
+		template.Resources[ingress] = &ec2.SecurityGroupIngress{
+			CidrIp:      "0.0.0.0/0",
+			Description: fmt.Sprintf("%s:%d/%s on %s network", service.Name, p, port.Protocol, net),
+			GroupId:     resources.securityGroups[net],
+			FromPort:    int(p),
+			IpProtocol:  protocol,
+			ToPort:      int(p),
+		}
 	}
 }
 
 func (b *ecsAPIService) createSecret(project *types.Project, name string, s types.SecretConfig, template *cloudformation.Template) error {
+	if err := checkSecretSource(name, s); err != nil {
+		return err
+	}
 	if s.External.External {
 		return nil
 	}
@@ -326,6 +491,31 @@ func (b *ecsAPIService) createLogGroup(project *types.Project, template *cloudfo
 	}
 }
 
+// computeDeploymentCircuitBreaker maps deploy.update_config.failure_action to ECS deployment circuit breaker, so that
+// a deployment which can't reach a steady state fails fast. As per compose specification, failed deployment is paused
+// by default
+func computeDeploymentCircuitBreaker(service types.ServiceConfig) (*ecs.Service_DeploymentCircuitBreaker, error) {
+	failureAction := "pause"
+	if service.Deploy != nil && service.Deploy.UpdateConfig != nil && service.Deploy.UpdateConfig.FailureAction != "" {
+		failureAction = service.Deploy.UpdateConfig.FailureAction
+	}
+	switch failureAction {
+	case "rollback":
+		return &ecs.Service_DeploymentCircuitBreaker{
+			Enable:   true,
+			Rollback: true,
+		}, nil
+	case "pause":
+		return &ecs.Service_DeploymentCircuitBreaker{
+			Enable: true,
+		}, nil
+	case "continue":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("service %s: unsupported deploy.update_config.failure_action %q", service.Name, failureAction)
+	}
+}
+
 func computeRollingUpdateLimits(service types.ServiceConfig) (int, int, error) {
 	maxPercent := 200
 	minPercent := 100
@@ -369,12 +559,7 @@ func computeRollingUpdateLimits(service types.ServiceConfig) (int, int, error) {
 func (b *ecsAPIService) createListener(service types.ServiceConfig, port types.ServicePortConfig,
 	template *cloudformation.Template,
 	targetGroupName string, loadBalancer awsResource, protocol string) string {
-	listenerName := fmt.Sprintf(
-		"%s%s%dListener",
-		normalizeResourceName(service.Name),
-		strings.ToUpper(port.Protocol),
-		port.Target,
-	)
+	listenerName := listenerResourceName(service.Name, port)
 	// add listener to dependsOn
 	// https://stackoverflow.com/questions/53971873/the-target-group-does-not-have-an-associated-load-balancer
 	template.Resources[listenerName] = &elasticloadbalancingv2.Listener{
@@ -392,42 +577,58 @@ func (b *ecsAPIService) createListener(service types.ServiceConfig, port types.S
 		},
 		LoadBalancerArn: loadBalancer.ARN(),
 		Protocol:        protocol,
-		Port:            int(port.Target),
+		Port:            int(port.Published),
 	}
 	return listenerName
 }
 
-func (b *ecsAPIService) createTargetGroup(project *types.Project, service types.ServiceConfig, port types.ServicePortConfig, template *cloudformation.Template, protocol string, vpc string) string {
-	targetGroupName := fmt.Sprintf(
+func listenerResourceName(service string, port types.ServicePortConfig) string {
+	return fmt.Sprintf(
+		"%s%s%dListener",
+		normalizeResourceName(service),
+		strings.ToUpper(port.Protocol),
+		port.Published,
+	)
+}
+
+func targetGroupResourceName(service string, port types.ServicePortConfig) string {
+	return fmt.Sprintf(
 		"%s%s%dTargetGroup",
-		normalizeResourceName(service.Name),
+		normalizeResourceName(service),
 		strings.ToUpper(port.Protocol),
 		port.Published,
 	)
-	template.Resources[targetGroupName] = &elasticloadbalancingv2.TargetGroup{
+}
+
+func (b *ecsAPIService) createTargetGroup(project *types.Project, service types.ServiceConfig, port types.ServicePortConfig, template *cloudformation.Template, protocol string, vpc string) (string, error) {
+	targetGroupName := targetGroupResourceName(service.Name, port)
+	healthCheck, err := getTargetHealthCheck(service, protocol)
+	if err != nil {
+		return "", err
+	}
+	targetGroup := &elasticloadbalancingv2.TargetGroup{
 		Port:       int(port.Target),
 		Protocol:   protocol,
 		Tags:       projectTags(project),
 		TargetType: elbv2.TargetTypeEnumIp,
 		VpcId:      vpc,
 	}
-	return targetGroupName
+	healthCheck.apply(targetGroup)
+	template.Resources[targetGroupName] = targetGroup
+	return targetGroupName, nil
 }
 
-func (b *ecsAPIService) createServiceRegistry(service types.ServiceConfig, template *cloudformation.Template, healthCheck *cloudmap.Service_HealthCheckConfig) ecs.Service_ServiceRegistry {
+func (b *ecsAPIService) createServiceRegistry(service types.ServiceConfig, template *cloudformation.Template, healthCheck *cloudmap.Service_HealthCheckCustomConfig) ecs.Service_ServiceRegistry {
 	serviceRegistration := fmt.Sprintf("%sServiceDiscoveryEntry", normalizeResourceName(service.Name))
 	serviceRegistry := ecs.Service_ServiceRegistry{
 		RegistryArn: cloudformation.GetAtt(serviceRegistration, "Arn"),
 	}
 
 	template.Resources[serviceRegistration] = &cloudmap.Service{
-		Description:       fmt.Sprintf("%q service discovery entry in Cloud Map", service.Name),
-		HealthCheckConfig: healthCheck,
-		HealthCheckCustomConfig: &cloudmap.Service_HealthCheckCustomConfig{
-			FailureThreshold: 1,
-		},
-		Name:        service.Name,
-		NamespaceId: cloudformation.Ref("CloudMap"),
+		Description:             fmt.Sprintf("%q service discovery entry in Cloud Map", service.Name),
+		HealthCheckCustomConfig: healthCheck,
+		Name:                    service.Name,
+		NamespaceId:             cloudformation.Ref("CloudMap"),
 		DnsConfig: &cloudmap.Service_DnsConfig{
 			DnsRecords: []cloudmap.Service_DnsRecord{
 				{
@@ -466,6 +667,9 @@ func (b *ecsAPIService) createTaskRole(project *types.Project, service types.Ser
 		})
 	}
 	for _, vol := range service.Volumes {
+		if vol.Type != types.VolumeTypeVolume {
+			continue
+		}
 		if vol.Source == "" {
 			return "", fmt.Errorf(
 				"service %s has an invalid volume %s: ECS does not support sourceless volumes",
@@ -475,7 +679,13 @@ func (b *ecsAPIService) createTaskRole(project *types.Project, service types.Ser
 		}
 		rolePolicies = append(rolePolicies, iam.Role_Policy{
 			PolicyName:     fmt.Sprintf("%s%sVolumeMountPolicy", normalizeResourceName(service.Name), normalizeResourceName(vol.Source)),
-			PolicyDocument: volumeMountPolicyDocument(vol.Source, resources.filesystems[vol.Source].ARN()),
+			PolicyDocument: volumeMountPolicyDocument(accessPointResourceName(service, vol.Source), resources.filesystems[vol.Source].ARN()),
+		})
+	}
+	if policy := logRouterPolicyDocument(service); policy != nil {
+		rolePolicies = append(rolePolicies, iam.Role_Policy{
+			PolicyName:     fmt.Sprintf("%sLogRouterPolicy", normalizeResourceName(service.Name)),
+			PolicyDocument: policy,
 		})
 	}
 	managedPolicies := []string{}
@@ -509,26 +719,50 @@ func (b *ecsAPIService) createPolicies(project *types.Project, service types.Ser
 	if value, ok := service.Extensions[extensionPullCredentials]; ok {
 		arns = append(arns, value.(string))
 	}
-	for _, secret := range service.Secrets {
-		arns = append(arns, project.Secrets[secret.Source].Name)
+	useSecureStrings := false
+	for _, s := range service.Secrets {
+		secret := project.Secrets[s.Source]
+		if getSecretSource(secret) == secretSourceSSM {
+			useSecureStrings = true
+			arns = append(arns, ssmParameterARN(secret.Name))
+			continue
+		}
+		arns = append(arns, secret.Name)
 	}
-	if len(arns) > 0 {
-		return []iam.Role_Policy{
-			{
-				PolicyDocument: &PolicyDocument{
-					Statement: []PolicyStatement{
-						{
-							Effect:   "Allow",
-							Action:   []string{actionGetSecretValue, actionGetParameters, actionDecrypt},
-							Resource: arns,
-						},
-					},
+	for _, config := range service.Configs {
+		arns = append(arns, ssmParameterARN(configParameterName(project, config.Source)))
+	}
+	if len(arns) == 0 {
+		return nil
+	}
+	statements := []PolicyStatement{
+		{
+			Effect:   "Allow",
+			Action:   []string{actionGetSecretValue, actionGetParameters, actionDecrypt},
+			Resource: arns,
+		},
+	}
+	if useSecureStrings {
+		// SecureString parameters can be encrypted by a customer managed key, which can't be guessed from parameter
+		statements = append(statements, PolicyStatement{
+			Effect:   "Allow",
+			Action:   []string{actionDecrypt},
+			Resource: []string{"*"},
+			Condition: Condition{
+				StringEquals: map[string]string{
+					"kms:ViaService": cloudformation.Sub("ssm.${AWS::Region}.amazonaws.com"),
 				},
-				PolicyName: fmt.Sprintf("%sGrantAccessToSecrets", service.Name),
 			},
-		}
+		})
+	}
+	return []iam.Role_Policy{
+		{
+			PolicyDocument: &PolicyDocument{
+				Statement: statements,
+			},
+			PolicyName: fmt.Sprintf("%sGrantAccessToSecrets", service.Name),
+		},
 	}
-	return nil
 }
 
 func networkResourceName(network string) string {
@@ -74,6 +74,72 @@ x-aws-logs_retention: 10
 	assert.Equal(t, logGroup.RetentionInDays, 10)
 }
 
+func TestFirelensLogging(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    logging:
+      driver: fluentbit
+      options:
+        Name: es
+        Host: elasticsearch.example.com
+        config-file-type: file
+        config-file-value: /fluent-bit/configs/parse-json.conf
+`, nil, useDefaultVPC)
+	def := template.Resources["FooTaskDefinition"].(*ecs.TaskDefinition)
+	main := getMainContainer(def, t)
+	assert.Equal(t, main.LogConfiguration.LogDriver, "awsfirelens")
+	assert.DeepEqual(t, main.LogConfiguration.Options, map[string]string{
+		"Name": "es",
+		"Host": "elasticsearch.example.com",
+	})
+	assert.DeepEqual(t, main.DependsOnProp[len(main.DependsOnProp)-1], ecs.TaskDefinition_ContainerDependency{
+		Condition:     "START",
+		ContainerName: "Foo_LogRouter",
+	})
+
+	router := def.ContainerDefinitions[len(def.ContainerDefinitions)-1]
+	assert.Equal(t, router.Name, "Foo_LogRouter")
+	assert.Equal(t, router.Image, logRouterImage)
+	assert.Equal(t, router.FirelensConfiguration.Type, "fluentbit")
+	assert.Equal(t, router.FirelensConfiguration.Options["config-file-type"], "file")
+	assert.Equal(t, router.LogConfiguration.LogDriver, "awslogs")
+	assert.Check(t, template.Resources["FooTaskRole"] == nil)
+}
+
+func TestFirelensS3ConfigFileRequiresEC2(t *testing.T) {
+	convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    logging:
+      driver: fluentbit
+      options:
+        Name: es
+        config-file-type: s3
+        config-file-value: arn:aws:s3:::bucket/fluent-bit.conf
+`, errors.New(`service foo: logging option config-file-type "s3" is not supported on Fargate, use "file"`), useDefaultVPC)
+}
+
+func TestFirelensOutputPluginPolicy(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    logging:
+      driver: fluentbit
+      options:
+        Name: kinesis_firehose
+        region: eu-west-1
+        delivery_stream: logs
+`, nil, useDefaultVPC)
+	role := template.Resources["FooTaskRole"].(*iam.Role)
+	assert.Equal(t, role.Policies[0].PolicyName, "FooLogRouterPolicy")
+	policy := role.Policies[0].PolicyDocument.(*PolicyDocument)
+	assert.DeepEqual(t, policy.Statement[0].Action, []string{"firehose:PutRecordBatch"})
+}
+
 func TestEnvFile(t *testing.T) {
 	template := convertYaml(t, `
 services:
@@ -146,6 +212,41 @@ services:
 	assert.Check(t, service.DeploymentConfiguration.MinimumHealthyPercent == 25)
 }
 
+func TestDeploymentCircuitBreaker(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+  bar:
+    image: hello_world
+    deploy:
+      update_config:
+        failure_action: rollback
+  zot:
+    image: hello_world
+    deploy:
+      update_config:
+        failure_action: continue
+`, nil, useDefaultVPC)
+	service := template.Resources["FooService"].(*ecs.Service)
+	assert.DeepEqual(t, service.DeploymentConfiguration.DeploymentCircuitBreaker, &ecs.Service_DeploymentCircuitBreaker{Enable: true})
+	service = template.Resources["BarService"].(*ecs.Service)
+	assert.DeepEqual(t, service.DeploymentConfiguration.DeploymentCircuitBreaker, &ecs.Service_DeploymentCircuitBreaker{Enable: true, Rollback: true})
+	service = template.Resources["ZotService"].(*ecs.Service)
+	assert.Check(t, service.DeploymentConfiguration.DeploymentCircuitBreaker == nil)
+}
+
+func TestDeploymentCircuitBreakerInvalidFailureAction(t *testing.T) {
+	convertYaml(t, `
+services:
+  foo:
+    image: hello_world
+    deploy:
+      update_config:
+        failure_action: retry
+`, errors.New(`service foo: unsupported deploy.update_config.failure_action "retry"`), useDefaultVPC)
+}
+
 func TestRolePolicy(t *testing.T) {
 	template := convertYaml(t, `
 services:
@@ -351,6 +452,36 @@ services:
 	assert.Check(t, loadBalancer.Type == elbv2.LoadBalancerTypeEnumNetwork)
 }
 
+func TestPublishedPortRemapping(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  test:
+    image: nginx
+    ports:
+      - 80:8080
+`, nil, useDefaultVPC)
+	loadBalancer := template.Resources["LoadBalancer"].(*elasticloadbalancingv2.LoadBalancer)
+	assert.Equal(t, loadBalancer.Type, elbv2.LoadBalancerTypeEnumApplication)
+
+	listener := template.Resources["TestTCP80Listener"].(*elasticloadbalancingv2.Listener)
+	assert.Equal(t, listener.Port, 80)
+	targetGroup := template.Resources["TestTCP80TargetGroup"].(*elasticloadbalancingv2.TargetGroup)
+	assert.Equal(t, targetGroup.Port, 8080)
+
+	service := template.Resources["TestService"].(*ecs.Service)
+	assert.Equal(t, service.LoadBalancers[0].ContainerPort, 8080)
+	def := template.Resources["TestTaskDefinition"].(*ecs.TaskDefinition)
+	assert.DeepEqual(t, getMainContainer(def, t).PortMappings, []ecs.TaskDefinition_PortMapping{
+		{ContainerPort: 8080, HostPort: 8080, Protocol: "tcp"},
+	})
+
+	for _, port := range []int{80, 8080} {
+		ingress := template.Resources[fmt.Sprintf("Default%dIngress", port)].(*ec2.SecurityGroupIngress)
+		assert.Equal(t, ingress.FromPort, port)
+		assert.Equal(t, ingress.ToPort, port)
+	}
+}
+
 func TestUseExternalNetwork(t *testing.T) {
 	template := convertYaml(t, `
 services:
@@ -19,6 +19,7 @@ package ecs
 import (
 	"fmt"
 
+	ecsapi "github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/compose-spec/compose-go/compatibility"
 	"github.com/compose-spec/compose-go/errdefs"
 	"github.com/compose-spec/compose-go/types"
@@ -48,10 +49,18 @@ func (b *ecsAPIService) checkCompatibility(project *types.Project) error {
 type fargateCompatibilityChecker struct {
 	compatibility.AllowList
 	projet *types.Project
+	// ec2 is set while checking volumes for a service running on EC2 instances
+	ec2 bool
 }
 
 var compatibleComposeAttributes = []string{
+	"services.build",
+	"services.build.args",
+	"services.build.cache_from",
+	"services.build.labels",
+	"services.build.target",
 	"services.command",
+	"services.configs",
 	"services.container_name",
 	"services.cap_drop",
 	"services.depends_on",
@@ -73,6 +82,7 @@ var compatibleComposeAttributes = []string{
 	"services.deploy.resources.reservations.generic_resources.discrete_resource_spec",
 	"services.deploy.update_config",
 	"services.deploy.update_config.parallelism",
+	"services.deploy.update_config.failure_action",
 	"services.entrypoint",
 	"services.environment",
 	"services.env_file",
@@ -99,7 +109,16 @@ var compatibleComposeAttributes = []string{
 	"services.volumes",
 	"services.volumes.read_only",
 	"services.volumes.target",
+	"services.volumes.tmpfs.size",
 	"services.working_dir",
+	"configs.external",
+	"configs.file",
+	"configs.gid",
+	"configs.mode",
+	"configs.name",
+	"configs.source",
+	"configs.target",
+	"configs.uid",
 	"secrets.external",
 	"secrets.name",
 	"secrets.file",
@@ -121,12 +140,17 @@ func (c *fargateCompatibilityChecker) CheckPortsPublished(p *types.ServicePortCo
 	if p.Published == 0 {
 		p.Published = p.Target
 	}
-	if p.Published != p.Target {
-		c.Incompatible("published port can't be set to a distinct value than container port")
-	}
+}
+
+func (c *fargateCompatibilityChecker) CheckServiceVolumes(service *types.ServiceConfig) bool {
+	c.ec2 = requireEC2(*service)
+	return c.AllowList.CheckServiceVolumes(service)
 }
 
 func (c *fargateCompatibilityChecker) CheckVolumesSource(config *types.ServiceVolumeConfig) {
+	if c.ec2 {
+		return
+	}
 	if config.Type == types.VolumeTypeBind {
 		c.Incompatible("ECS Fargate does not support bind mounts from host")
 	}
@@ -135,6 +159,12 @@ func (c *fargateCompatibilityChecker) CheckVolumesSource(config *types.ServiceVo
 	}
 }
 
+func (c *fargateCompatibilityChecker) CheckTmpfs(service *types.ServiceConfig) {
+	if len(service.Tmpfs) > 0 && !requireEC2(*service) {
+		c.Incompatible("ECS Fargate does not support tmpfs")
+	}
+}
+
 func (c *fargateCompatibilityChecker) CheckCapAdd(service *types.ServiceConfig) {
 	add := []string{}
 	for _, cap := range service.CapAdd {
@@ -149,7 +179,9 @@ func (c *fargateCompatibilityChecker) CheckCapAdd(service *types.ServiceConfig)
 }
 
 func (c *fargateCompatibilityChecker) CheckLoggingDriver(config *types.LoggingConfig) {
-	if config.Driver != "" && config.Driver != "awslogs" {
+	switch config.Driver {
+	case "", ecsapi.LogDriverAwslogs, ecsapi.LogDriverAwsfirelens, logDriverFluentbit:
+	default:
 		c.Unsupported("services.logging.driver %s is not supported", config.Driver)
 	}
 }
@@ -0,0 +1,246 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	ecsapi "github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/ssm"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+const configsInitContainerImage = "public.ecr.aws/docker/library/busybox:stable"
+
+// configsSeedPath is where the seed container mounts configs volumes to copy the image content into
+const configsSeedPath = "/compose-configs"
+
+// SSM Parameter Store size limits, see https://docs.aws.amazon.com/systems-manager/latest/userguide/parameter-store-advanced-parameters.html
+const (
+	maxStandardParameterSize = 4 * 1024
+	maxAdvancedParameterSize = 8 * 1024
+)
+
+const defaultConfigMode = 0444
+
+// numericIDPattern matches the uid and gid a config file can be owned by
+var numericIDPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// shellQuote quotes s as a single word for the init container shell script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func configResourceName(config string) string {
+	return fmt.Sprintf("%sConfig", normalizeResourceName(config))
+}
+
+func configParameterName(project *types.Project, name string) string {
+	config := project.Configs[name]
+	if config.External.External {
+		return config.Name
+	}
+	return fmt.Sprintf("/%s/configs/%s", project.Name, name)
+}
+
+// createConfig stores config content in SSM Parameter Store. Content is read from config file, or set inline by
+// x-aws-content. External configs are expected to reference an existing parameter by name or ARN.
+func (b *ecsAPIService) createConfig(project *types.Project, name string, config types.ConfigObjConfig, template *cloudformation.Template) error {
+	if config.External.External {
+		return nil
+	}
+	var content string
+	if inline, ok := config.Extensions[extensionContent]; ok {
+		content = fmt.Sprint(inline)
+	} else {
+		data, err := os.ReadFile(config.File)
+		if err != nil {
+			return err
+		}
+		content = string(data)
+	}
+
+	tier := "Standard"
+	switch {
+	case content == "":
+		return fmt.Errorf("config %s: content can't be empty", name)
+	case len(content) > maxAdvancedParameterSize:
+		return fmt.Errorf("config %s: content exceeds the maximum size of %d bytes", name, maxAdvancedParameterSize)
+	case len(content) > maxStandardParameterSize:
+		tier = "Advanced"
+	}
+
+	template.Resources[configResourceName(name)] = &ssm.Parameter{
+		Description: fmt.Sprintf("Config %s", name),
+		Name:        configParameterName(project, name),
+		Tags: map[string]string{
+			api.ProjectLabel: project.Name,
+		},
+		Tier:  tier,
+		Type:  "String",
+		Value: content,
+	}
+	return nil
+}
+
+// createConfigsInitContainer declares an init container to write configs content, passed as task secrets, into
+// volumes shared with service container. One volume is created per directory configs are mounted into. As this volume
+// would otherwise hide the directory content from the image, a seed container first copies it from the service image.
+// A config directory can't be the parent of another one.
+func createConfigsInitContainer(project *types.Project, service types.ServiceConfig, logConfiguration *ecs.TaskDefinition_LogConfiguration) (
+	[]ecs.TaskDefinition_Volume,
+	[]ecs.TaskDefinition_MountPoint,
+	[]ecs.TaskDefinition_ContainerDefinition,
+	error) {
+	var (
+		volumes     []ecs.TaskDefinition_Volume
+		mounts      []ecs.TaskDefinition_MountPoint
+		initMounts  []ecs.TaskDefinition_MountPoint
+		seedMounts  []ecs.TaskDefinition_MountPoint
+		taskSecrets []ecs.TaskDefinition_Secret
+		seed        []string
+		script      []string
+	)
+	dirs := map[string]bool{}
+	for i, c := range service.Configs {
+		target := c.Target
+		if target == "" {
+			target = c.Source
+		}
+		if !path.IsAbs(target) {
+			target = "/" + target
+		}
+		dir := path.Dir(target)
+		if dir == "/" {
+			return nil, nil, nil, fmt.Errorf("service %s: config %s can't be mounted in root directory, target must be set", service.Name, c.Source)
+		}
+
+		if !dirs[dir] {
+			dirs[dir] = true
+			// index suffix keeps names unique for directories which normalize alike, like /etc/a-b and /etc/ab
+			volume := fmt.Sprintf("configs%s_%d", normalizeResourceName(dir), len(volumes))
+			volumes = append(volumes, ecs.TaskDefinition_Volume{
+				Name: volume,
+			})
+			mounts = append(mounts, ecs.TaskDefinition_MountPoint{
+				ContainerPath: dir,
+				ReadOnly:      true,
+				SourceVolume:  volume,
+			})
+			initMounts = append(initMounts, ecs.TaskDefinition_MountPoint{
+				ContainerPath: dir,
+				ReadOnly:      false,
+				SourceVolume:  volume,
+			})
+			seedPath := path.Join(configsSeedPath, volume)
+			seedMounts = append(seedMounts, ecs.TaskDefinition_MountPoint{
+				ContainerPath: seedPath,
+				ReadOnly:      false,
+				SourceVolume:  volume,
+			})
+			seed = append(seed, fmt.Sprintf("if [ -d %s ]; then cp -a %s %s; fi", shellQuote(dir), shellQuote(dir+"/."), shellQuote(seedPath+"/")))
+		}
+
+		variable := fmt.Sprintf("COMPOSE_CONFIG_%d", i)
+		valueFrom := configParameterName(project, c.Source)
+		if !project.Configs[c.Source].External.External {
+			valueFrom = cloudformation.Ref(configResourceName(c.Source))
+		}
+		taskSecrets = append(taskSecrets, ecs.TaskDefinition_Secret{
+			Name:      variable,
+			ValueFrom: valueFrom,
+		})
+
+		mode := uint32(defaultConfigMode)
+		if c.Mode != nil {
+			mode = *c.Mode
+		}
+		file := shellQuote(target)
+		script = append(script, fmt.Sprintf("printf '%%s' \"$%s\" > %s", variable, file))
+		if c.UID != "" || c.GID != "" {
+			for _, id := range []string{c.UID, c.GID} {
+				if id != "" && !numericIDPattern.MatchString(id) {
+					return nil, nil, nil, fmt.Errorf("service %s: config %s uid and gid must be numeric, got %q", service.Name, c.Source, id)
+				}
+			}
+			owner := c.UID
+			if c.GID != "" {
+				owner += ":" + c.GID
+			}
+			script = append(script, fmt.Sprintf("chown %s %s", owner, file))
+		}
+		script = append(script, fmt.Sprintf("chmod %o %s", mode, file))
+	}
+
+	if err := checkConfigsDirectories(service, dirs); err != nil {
+		return nil, nil, nil, err
+	}
+
+	seedContainer := ecs.TaskDefinition_ContainerDefinition{
+		Name:                  fmt.Sprintf("%s_Configs_SeedContainer", normalizeResourceName(service.Name)),
+		Image:                 service.Image,
+		EntryPoint:            []string{"/bin/sh", "-c"},
+		Command:               []string{strings.Join(seed, " && ")},
+		Essential:             false,
+		LogConfiguration:      logConfiguration,
+		MountPoints:           seedMounts,
+		RepositoryCredentials: getRepoCredentials(service),
+	}
+	initContainer := ecs.TaskDefinition_ContainerDefinition{
+		Name:       fmt.Sprintf("%s_Configs_InitContainer", normalizeResourceName(service.Name)),
+		Image:      configsInitContainerImage,
+		EntryPoint: []string{"/bin/sh", "-c"},
+		Command:    []string{strings.Join(script, " && ")},
+		DependsOnProp: []ecs.TaskDefinition_ContainerDependency{
+			{
+				Condition:     ecsapi.ContainerConditionSuccess,
+				ContainerName: seedContainer.Name,
+			},
+		},
+		Essential:        false,
+		LogConfiguration: logConfiguration,
+		MountPoints:      initMounts,
+		Secrets:          taskSecrets,
+	}
+	return volumes, mounts, []ecs.TaskDefinition_ContainerDefinition{seedContainer, initContainer}, nil
+}
+
+// checkConfigsDirectories rejects configs directories nested into each other, as the volume mounted on the parent
+// directory would hide the one mounted on the child directory
+func checkConfigsDirectories(service types.ServiceConfig, dirs map[string]bool) error {
+	var sorted []string
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+	for _, parent := range sorted {
+		for _, child := range sorted {
+			if strings.HasPrefix(child, parent+"/") {
+				return fmt.Errorf("service %s: configs can't be mounted both in %s and %s, as the former would hide the latter. Use a dedicated directory for configs", service.Name, parent, child)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/iam"
+	"github.com/awslabs/goformation/v4/cloudformation/ssm"
+	"gotest.tools/v3/assert"
+)
+
+func TestConfigs(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  web:
+    image: nginx
+    configs:
+      - source: site
+        target: /etc/nginx/conf.d/site.conf
+        mode: 0440
+        uid: "101"
+      - source: shared
+        target: /etc/nginx/conf.d/shared.conf
+configs:
+  site:
+    x-aws-content: |
+      server { listen 80; }
+  shared:
+    external: true
+    name: /company/nginx/shared
+`, nil, useDefaultVPC)
+	parameter := template.Resources["SiteConfig"].(*ssm.Parameter)
+	assert.Equal(t, parameter.Name, "/TestConfigs/configs/site")
+	assert.Equal(t, parameter.Value, "server { listen 80; }\n")
+	assert.Equal(t, parameter.Tier, "Standard")
+	assert.Check(t, template.Resources["SharedConfig"] == nil)
+
+	def := template.Resources["WebTaskDefinition"].(*ecs.TaskDefinition)
+	seed := def.ContainerDefinitions[0]
+	assert.Equal(t, seed.Name, "Web_Configs_SeedContainer")
+	assert.Equal(t, seed.Image, "nginx")
+	assert.DeepEqual(t, seed.Command, []string{
+		`if [ -d '/etc/nginx/conf.d' ]; then cp -a '/etc/nginx/conf.d/.' '/compose-configs/configsEtcnginxconfd_0/'; fi`,
+	})
+	assert.DeepEqual(t, seed.MountPoints, []ecs.TaskDefinition_MountPoint{
+		{ContainerPath: "/compose-configs/configsEtcnginxconfd_0", ReadOnly: false, SourceVolume: "configsEtcnginxconfd_0"},
+	})
+
+	init := def.ContainerDefinitions[1]
+	assert.Equal(t, init.Name, "Web_Configs_InitContainer")
+	assert.DeepEqual(t, init.DependsOnProp, []ecs.TaskDefinition_ContainerDependency{
+		{Condition: "SUCCESS", ContainerName: "Web_Configs_SeedContainer"},
+	})
+	assert.DeepEqual(t, init.Secrets, []ecs.TaskDefinition_Secret{
+		{Name: "COMPOSE_CONFIG_0", ValueFrom: cloudformation.Ref("SiteConfig")},
+		{Name: "COMPOSE_CONFIG_1", ValueFrom: "/company/nginx/shared"},
+	})
+	assert.DeepEqual(t, init.Command, []string{
+		`printf '%s' "$COMPOSE_CONFIG_0" > '/etc/nginx/conf.d/site.conf' && chown 101 '/etc/nginx/conf.d/site.conf' && chmod 440 '/etc/nginx/conf.d/site.conf' && ` +
+			`printf '%s' "$COMPOSE_CONFIG_1" > '/etc/nginx/conf.d/shared.conf' && chmod 444 '/etc/nginx/conf.d/shared.conf'`,
+	})
+
+	main := getMainContainer(def, t)
+	assert.DeepEqual(t, main.MountPoints, []ecs.TaskDefinition_MountPoint{
+		{ContainerPath: "/etc/nginx/conf.d", ReadOnly: true, SourceVolume: "configsEtcnginxconfd_0"},
+	})
+	assert.DeepEqual(t, main.DependsOnProp[1], ecs.TaskDefinition_ContainerDependency{
+		Condition:     "SUCCESS",
+		ContainerName: "Web_Configs_InitContainer",
+	})
+
+	role := template.Resources["WebTaskExecutionRole"].(*iam.Role)
+	policy := role.Policies[0].PolicyDocument.(*PolicyDocument)
+	assert.DeepEqual(t, policy.Statement[0].Resource, []string{
+		cloudformation.Sub("arn:${AWS::Partition}:ssm:${AWS::Region}:${AWS::AccountId}:parameter/TestConfigs/configs/site"),
+		cloudformation.Sub("arn:${AWS::Partition}:ssm:${AWS::Region}:${AWS::AccountId}:parameter/company/nginx/shared"),
+	})
+}
+
+func TestConfigsRootTarget(t *testing.T) {
+	convertYaml(t, `
+services:
+  web:
+    image: nginx
+    configs:
+      - site
+configs:
+  site:
+    x-aws-content: "server { listen 80; }"
+`, errors.New("service web: config site can't be mounted in root directory, target must be set"), useDefaultVPC)
+}
+
+func TestConfigsNestedDirectories(t *testing.T) {
+	convertYaml(t, `
+services:
+  web:
+    image: nginx
+    configs:
+      - source: site
+        target: /etc/nginx/conf.d/site.conf
+      - source: main
+        target: /etc/nginx/nginx.conf
+configs:
+  site:
+    x-aws-content: "server { listen 80; }"
+  main:
+    x-aws-content: "events {}"
+`, errors.New("service web: configs can't be mounted both in /etc/nginx and /etc/nginx/conf.d, as the former would hide the latter. Use a dedicated directory for configs"), useDefaultVPC)
+}
+
+func TestConfigsInvalidOwner(t *testing.T) {
+	convertYaml(t, `
+services:
+  web:
+    image: nginx
+    configs:
+      - source: site
+        target: /etc/nginx/conf.d/site.conf
+        uid: "0; rm -rf /"
+configs:
+  site:
+    x-aws-content: "server { listen 80; }"
+`, errors.New(`service web: config site uid and gid must be numeric, got "0; rm -rf /"`), useDefaultVPC)
+}
+
+func TestConfigsQuoteTarget(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  web:
+    image: nginx
+    configs:
+      - source: site
+        target: "/etc/nginx/conf.d/it's.conf"
+configs:
+  site:
+    x-aws-content: "server { listen 80; }"
+`, nil, useDefaultVPC)
+	def := template.Resources["WebTaskDefinition"].(*ecs.TaskDefinition)
+	assert.DeepEqual(t, def.ContainerDefinitions[1].Command, []string{
+		`printf '%s' "$COMPOSE_CONFIG_0" > '/etc/nginx/conf.d/it'\''s.conf' && chmod 444 '/etc/nginx/conf.d/it'\''s.conf'`,
+	})
+}
+
+func TestConfigsVolumeNames(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  web:
+    image: nginx
+    configs:
+      - source: site
+        target: /etc/a-b/site.conf
+      - source: site
+        target: /etc/ab/site.conf
+configs:
+  site:
+    x-aws-content: "server { listen 80; }"
+`, nil, useDefaultVPC)
+	def := template.Resources["WebTaskDefinition"].(*ecs.TaskDefinition)
+	main := getMainContainer(def, t)
+	assert.DeepEqual(t, main.MountPoints, []ecs.TaskDefinition_MountPoint{
+		{ContainerPath: "/etc/a-b", ReadOnly: true, SourceVolume: "configsEtcab_0"},
+		{ContainerPath: "/etc/ab", ReadOnly: true, SourceVolume: "configsEtcab_1"},
+	})
+	assert.DeepEqual(t, def.Volumes, []ecs.TaskDefinition_Volume{
+		{Name: "configsEtcab_0"},
+		{Name: "configsEtcab_1"},
+	})
+}
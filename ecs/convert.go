@@ -33,6 +33,7 @@ import (
 	"github.com/awslabs/goformation/v4/cloudformation/ecs"
 	"github.com/compose-spec/compose-go/types"
 	"github.com/docker/cli/opts"
+	"github.com/docker/go-units"
 	"github.com/joho/godotenv"
 )
 
@@ -49,29 +50,11 @@ func (b *ecsAPIService) createTaskDefinition(project *types.Project, service typ
 
 	logConfiguration := getLogConfiguration(service, project)
 
-	var (
-		initContainers []ecs.TaskDefinition_ContainerDefinition
-		volumes        []ecs.TaskDefinition_Volume
-		mounts         []ecs.TaskDefinition_MountPoint
-	)
-	if len(service.Secrets) > 0 {
-		secretsVolume, secretsMount, secretsSideCar, err := createSecretsSideCar(project, service, logConfiguration)
-		if err != nil {
-			return nil, err
-		}
-		initContainers = append(initContainers, secretsSideCar)
-		volumes = append(volumes, secretsVolume)
-		mounts = append(mounts, secretsMount)
+	initContainers, volumes, mounts, err := b.createInitContainers(project, service, logConfiguration)
+	if err != nil {
+		return nil, err
 	}
 
-	initContainers = append(initContainers, ecs.TaskDefinition_ContainerDefinition{
-		Name:             fmt.Sprintf("%s_ResolvConf_InitContainer", normalizeResourceName(service.Name)),
-		Image:            searchDomainInitContainerImage,
-		Essential:        false,
-		Command:          []string{b.Region + ".compute.internal", project.Name + ".local"},
-		LogConfiguration: logConfiguration,
-	})
-
 	var dependencies []ecs.TaskDefinition_ContainerDependency
 	for _, c := range initContainers {
 		dependencies = append(dependencies, ecs.TaskDefinition_ContainerDependency{
@@ -80,8 +63,35 @@ func (b *ecsAPIService) createTaskDefinition(project *types.Project, service typ
 		})
 	}
 
+	var sidecars []ecs.TaskDefinition_ContainerDefinition
+	serviceLogConfiguration := logConfiguration
+	if useFirelens(service) {
+		var logRouter ecs.TaskDefinition_ContainerDefinition
+		logRouter, serviceLogConfiguration, err = createLogRouter(service, logConfiguration)
+		if err != nil {
+			return nil, err
+		}
+		sidecars = append(sidecars, logRouter)
+		dependencies = append(dependencies, ecs.TaskDefinition_ContainerDependency{
+			Condition:     ecsapi.ContainerConditionStart,
+			ContainerName: logRouter.Name,
+		})
+	}
+
+	var tmpfs []ecs.TaskDefinition_Tmpfs
+	hostVolumes := map[string]string{}
 	for _, v := range service.Volumes {
-		n := fmt.Sprintf("%sAccessPoint", normalizeResourceName(v.Source))
+		switch v.Type {
+		case types.VolumeTypeBind:
+			hostVolume, hostMount := toHostVolume(v, hostVolumes)
+			volumes = append(volumes, hostVolume...)
+			mounts = append(mounts, hostMount)
+			continue
+		case types.VolumeTypeTmpfs:
+			tmpfs = append(tmpfs, toTmpfsVolume(v))
+			continue
+		}
+		n := accessPointResourceName(service, v.Source)
 		volumes = append(volumes, ecs.TaskDefinition_Volume{
 			EFSVolumeConfiguration: &ecs.TaskDefinition_EFSVolumeConfiguration{
 				AuthorizationConfig: &ecs.TaskDefinition_AuthorizationConfig{
@@ -109,6 +119,7 @@ func (b *ecsAPIService) createTaskDefinition(project *types.Project, service typ
 		reservations = service.Deploy.Resources.Reservations
 	}
 
+	serviceContainer := len(initContainers)
 	containers := append(initContainers, ecs.TaskDefinition_ContainerDefinition{
 		Command:                service.Command,
 		DisableNetworking:      service.NetworkMode == "none",
@@ -121,14 +132,13 @@ func (b *ecsAPIService) createTaskDefinition(project *types.Project, service typ
 		Environment:            pairs,
 		Essential:              true,
 		ExtraHosts:             toHostEntryPtr(service.ExtraHosts),
-		FirelensConfiguration:  nil,
 		HealthCheck:            toHealthCheck(service.HealthCheck),
 		Hostname:               service.Hostname,
 		Image:                  service.Image,
 		Interactive:            false,
 		Links:                  nil,
-		LinuxParameters:        toLinuxParameters(service),
-		LogConfiguration:       logConfiguration,
+		LinuxParameters:        toLinuxParameters(service, tmpfs),
+		LogConfiguration:       serviceLogConfiguration,
 		MemoryReservation:      memReservation,
 		MountPoints:            mounts,
 		Name:                   service.Name,
@@ -146,22 +156,29 @@ func (b *ecsAPIService) createTaskDefinition(project *types.Project, service typ
 		VolumesFrom:            nil,
 		WorkingDirectory:       service.WorkingDir,
 	})
+	containers = append(containers, sidecars...)
 
 	launchType := ecsapi.LaunchTypeFargate
 	if requireEC2(service) {
 		launchType = ecsapi.LaunchTypeEc2
 	}
 
+	var metadata map[string]interface{}
+	if useServiceConnect(project) && len(service.Ports) > 0 {
+		metadata = serviceConnectPortMappings(service, serviceContainer)
+	}
+
 	return &ecs.TaskDefinition{
-		ContainerDefinitions: containers,
-		Cpu:                  cpu,
-		Family:               fmt.Sprintf("%s-%s", project.Name, service.Name),
-		IpcMode:              service.Ipc,
-		Memory:               mem,
-		NetworkMode:          ecsapi.NetworkModeAwsvpc, // FIXME could be set by service.NetworkMode, Fargate only supports network mode ‘awsvpc’.
-		PidMode:              service.Pid,
-		PlacementConstraints: toPlacementConstraints(service.Deploy),
-		ProxyConfiguration:   nil,
+		AWSCloudFormationMetadata: metadata,
+		ContainerDefinitions:      containers,
+		Cpu:                       cpu,
+		Family:                    fmt.Sprintf("%s-%s", project.Name, service.Name),
+		IpcMode:                   service.Ipc,
+		Memory:                    mem,
+		NetworkMode:               ecsapi.NetworkModeAwsvpc, // FIXME could be set by service.NetworkMode, Fargate only supports network mode ‘awsvpc’.
+		PidMode:                   service.Pid,
+		PlacementConstraints:      toPlacementConstraints(service.Deploy),
+		ProxyConfiguration:        nil,
 		RequiresCompatibilities: []string{
 			launchType,
 		},
@@ -169,6 +186,50 @@ func (b *ecsAPIService) createTaskDefinition(project *types.Project, service typ
 	}, nil
 }
 
+// createInitContainers declares the containers to run before service container, to set up secrets, configs and DNS
+// search domain, along with the volumes and mounts they populate
+func (b *ecsAPIService) createInitContainers(project *types.Project, service types.ServiceConfig, logConfiguration *ecs.TaskDefinition_LogConfiguration) (
+	[]ecs.TaskDefinition_ContainerDefinition,
+	[]ecs.TaskDefinition_Volume,
+	[]ecs.TaskDefinition_MountPoint,
+	error) {
+	var (
+		initContainers []ecs.TaskDefinition_ContainerDefinition
+		volumes        []ecs.TaskDefinition_Volume
+		mounts         []ecs.TaskDefinition_MountPoint
+	)
+	if len(service.Secrets) > 0 {
+		secretsVolume, secretsMount, secretsSideCar, err := createSecretsSideCar(project, service, logConfiguration)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		initContainers = append(initContainers, secretsSideCar)
+		volumes = append(volumes, secretsVolume)
+		mounts = append(mounts, secretsMount)
+	}
+
+	if len(service.Configs) > 0 {
+		configsVolumes, configsMounts, configsInitContainers, err := createConfigsInitContainer(project, service, logConfiguration)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		initContainers = append(initContainers, configsInitContainers...)
+		volumes = append(volumes, configsVolumes...)
+		mounts = append(mounts, configsMounts...)
+	}
+
+	if !useServiceConnect(project) {
+		initContainers = append(initContainers, ecs.TaskDefinition_ContainerDefinition{
+			Name:             fmt.Sprintf("%s_ResolvConf_InitContainer", normalizeResourceName(service.Name)),
+			Image:            searchDomainInitContainerImage,
+			Essential:        false,
+			Command:          []string{b.Region + ".compute.internal", project.Name + ".local"},
+			LogConfiguration: logConfiguration,
+		})
+	}
+	return initContainers, volumes, mounts, nil
+}
+
 func toTaskResourceRequirements(reservations *types.Resource) []ecs.TaskDefinition_ResourceRequirement {
 	if reservations == nil {
 		return nil
@@ -448,6 +509,10 @@ func toPlacementConstraints(deploy *types.DeployConfig) []ecs.TaskDefinition_Tas
 	}
 	pl := []ecs.TaskDefinition_TaskDefinitionPlacementConstraint{}
 	for _, c := range deploy.Placement.Constraints {
+		if strings.HasPrefix(c, placementConstraintAMI) || strings.HasPrefix(c, placementConstraintMachine) {
+			// used to select the capacity provider
+			continue
+		}
 		pl = append(pl, ecs.TaskDefinition_TaskDefinitionPlacementConstraint{
 			Expression: c,
 			Type:       "",
@@ -464,7 +529,7 @@ func toPortMappings(ports []types.ServicePortConfig) []ecs.TaskDefinition_PortMa
 	for _, p := range ports {
 		m = append(m, ecs.TaskDefinition_PortMapping{
 			ContainerPort: int(p.Target),
-			HostPort:      int(p.Published),
+			HostPort:      int(p.Target), // awsvpc network mode requires host port to match container port
 			Protocol:      p.Protocol,
 		})
 	}
@@ -486,7 +551,7 @@ func toUlimits(ulimits map[string]*types.UlimitsConfig) []ecs.TaskDefinition_Uli
 	return u
 }
 
-func toLinuxParameters(service types.ServiceConfig) *ecs.TaskDefinition_LinuxParameters {
+func toLinuxParameters(service types.ServiceConfig, tmpfs []ecs.TaskDefinition_Tmpfs) *ecs.TaskDefinition_LinuxParameters {
 	return &ecs.TaskDefinition_LinuxParameters{
 		Capabilities:       toKernelCapabilities(service.CapAdd, service.CapDrop),
 		Devices:            nil,
@@ -494,7 +559,7 @@ func toLinuxParameters(service types.ServiceConfig) *ecs.TaskDefinition_LinuxPar
 		MaxSwap:            0,
 		// FIXME SharedMemorySize:   service.ShmSize,
 		Swappiness: 0,
-		Tmpfs:      toTmpfs(service.Tmpfs),
+		Tmpfs:      append(toTmpfs(service.Tmpfs), tmpfs...),
 	}
 }
 
@@ -512,6 +577,42 @@ func toTmpfs(tmpfs types.StringList) []ecs.TaskDefinition_Tmpfs {
 	return o
 }
 
+// toTmpfsVolume converts a tmpfs service volume, size being expressed in MiB on ECS
+func toTmpfsVolume(v types.ServiceVolumeConfig) ecs.TaskDefinition_Tmpfs {
+	size := 100
+	if v.Tmpfs != nil && v.Tmpfs.Size > 0 {
+		size = int((v.Tmpfs.Size + units.MiB - 1) / units.MiB)
+	}
+	return ecs.TaskDefinition_Tmpfs{
+		ContainerPath: v.Target,
+		Size:          size,
+	}
+}
+
+// toHostVolume converts a bind mount to a task definition host volume, source path being set on EC2 instance
+// toHostVolume declares one volume per distinct host path, shared by all mounts of this path. hostVolumes tracks the
+// volumes already declared by host path, so only new ones are returned
+func toHostVolume(v types.ServiceVolumeConfig, hostVolumes map[string]string) ([]ecs.TaskDefinition_Volume, ecs.TaskDefinition_MountPoint) {
+	var volumes []ecs.TaskDefinition_Volume
+	name, ok := hostVolumes[v.Source]
+	if !ok {
+		// index suffix keeps names unique for paths which normalize alike, like /var/log and /varlog
+		name = fmt.Sprintf("host%s_%d", normalizeResourceName(v.Source), len(hostVolumes))
+		hostVolumes[v.Source] = name
+		volumes = append(volumes, ecs.TaskDefinition_Volume{
+			Host: &ecs.TaskDefinition_HostVolumeProperties{
+				SourcePath: v.Source,
+			},
+			Name: name,
+		})
+	}
+	return volumes, ecs.TaskDefinition_MountPoint{
+		ContainerPath: v.Target,
+		ReadOnly:      v.ReadOnly,
+		SourceVolume:  name,
+	}
+}
+
 func toKernelCapabilities(add []string, drop []string) *ecs.TaskDefinition_KernelCapabilities {
 	if len(add) == 0 && len(drop) == 0 {
 		return nil
@@ -570,8 +671,13 @@ func getRepoCredentials(service types.ServiceConfig) *ecs.TaskDefinition_Reposit
 	return nil
 }
 
+// requireEC2 checks if service must run on EC2, as it requires GPUs or a user-defined machine type or AMI
 func requireEC2(s types.ServiceConfig) bool {
-	return gpuRequirements(s) > 0
+	if gpuRequirements(s) > 0 {
+		return true
+	}
+	ami, machineType := getUserDefinedMachine(s)
+	return ami != "" || machineType != ""
 }
 
 func gpuRequirements(s types.ServiceConfig) int64 {
@@ -0,0 +1,334 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"bytes"
+	"context"
+	_ "embed" // bundled price table
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	ecsapi "github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/applicationautoscaling"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/efs"
+	"github.com/awslabs/goformation/v4/cloudformation/elasticloadbalancingv2"
+	"github.com/awslabs/goformation/v4/cloudformation/logs"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/compose/v2/cmd/formatter"
+)
+
+// bundledPrices is the per-region price table used to estimate costs offline. Prices are indicative, on-demand, and
+// must be updated in prices.json when AWS pricing changes
+//
+//go:embed prices.json
+var bundledPrices []byte
+
+// hoursPerMonth is the average number of hours in a month, as used by AWS for monthly pricing
+const hoursPerMonth = 730
+
+// sharedResource is reported as service for resources used by all services
+const sharedResource = "-"
+
+type regionPrices struct {
+	FargateVCPUHour                float64 `json:"fargate_vcpu_hour"`
+	FargateGBHour                  float64 `json:"fargate_gb_hour"`
+	FargateSpotVCPUHour            float64 `json:"fargate_spot_vcpu_hour"`
+	FargateSpotGBHour              float64 `json:"fargate_spot_gb_hour"`
+	ApplicationLoadBalancerHour    float64 `json:"application_load_balancer_hour"`
+	ApplicationLoadBalancerLCUHour float64 `json:"application_load_balancer_lcu_hour"`
+	NetworkLoadBalancerHour        float64 `json:"network_load_balancer_hour"`
+	NetworkLoadBalancerLCUHour     float64 `json:"network_load_balancer_lcu_hour"`
+	EFSGBMonth                     float64 `json:"efs_gb_month"`
+	NATHour                        float64 `json:"nat_hour"`
+	NATGB                          float64 `json:"nat_gb"`
+	LogsIngestedGB                 float64 `json:"logs_ingested_gb"`
+	LogsStoredGBMonth              float64 `json:"logs_stored_gb_month"`
+}
+
+func getRegionPrices(region string) (regionPrices, error) {
+	var table map[string]regionPrices
+	if err := json.Unmarshal(bundledPrices, &table); err != nil {
+		return regionPrices{}, err
+	}
+	prices, ok := table[region]
+	if !ok {
+		return regionPrices{}, fmt.Errorf("no price data for region %q", region)
+	}
+	return prices, nil
+}
+
+func isCostEstimate(project *types.Project) bool {
+	cost, ok := project.Extensions[ExtensionCost].(bool)
+	return ok && cost
+}
+
+// costItem is the estimated cost of a resource. Monthly is a fixed cost, and MonthlyMax the fixed cost at maximum
+// scale for a service which autoscales. Usage describes the price of resources billed by consumption, which can't be
+// estimated from the template
+type costItem struct {
+	Service     string
+	Resource    string
+	Description string
+	Monthly     float64
+	MonthlyMax  float64
+	Usage       string
+}
+
+func (item costItem) maxMonthly() float64 {
+	if item.MonthlyMax > item.Monthly {
+		return item.MonthlyMax
+	}
+	return item.Monthly
+}
+
+func (item costItem) cost() string {
+	var costs []string
+	if item.maxMonthly() != 0 || item.Usage == "" {
+		costs = append(costs, formatMonthlyCost(item.Monthly, item.maxMonthly()))
+	}
+	if item.Usage != "" {
+		costs = append(costs, item.Usage)
+	}
+	return strings.Join(costs, " + ")
+}
+
+func formatMonthlyCost(min float64, max float64) string {
+	if max > min {
+		return fmt.Sprintf("$%.2f-$%.2f", min, max)
+	}
+	return fmt.Sprintf("$%.2f", min)
+}
+
+// estimateCost renders the estimated monthly cost of running the application, per service and in total
+func (b *ecsAPIService) estimateCost(ctx context.Context, project *types.Project) ([]byte, error) {
+	prices, err := getRegionPrices(b.Region)
+	if err != nil {
+		return nil, err
+	}
+	template, err := b.convert(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	items, err := estimateTemplateCost(project, template, prices)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := printCostEstimate(&out, items, b.Region); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// estimateTemplateCost walks template to estimate cost of services' Fargate tasks and shared resources
+func estimateTemplateCost(project *types.Project, template *cloudformation.Template, prices regionPrices) ([]costItem, error) {
+	var items []costItem
+	for _, service := range project.Services {
+		serviceItems, err := estimateServiceCost(service, template, prices)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, serviceItems...)
+	}
+
+	for id, r := range template.Resources {
+		switch resource := r.(type) {
+		case *elasticloadbalancingv2.LoadBalancer:
+			hourly, lcu := prices.NetworkLoadBalancerHour, prices.NetworkLoadBalancerLCUHour
+			if resource.Type == elbv2.LoadBalancerTypeEnumApplication {
+				hourly, lcu = prices.ApplicationLoadBalancerHour, prices.ApplicationLoadBalancerLCUHour
+			}
+			items = append(items, costItem{
+				Service:     sharedResource,
+				Resource:    id,
+				Description: fmt.Sprintf("%s load balancer", resource.Type),
+				Monthly:     hourly * hoursPerMonth,
+				Usage:       fmt.Sprintf("$%g/LCU-hour", lcu),
+			})
+		case *efs.FileSystem:
+			items = append(items, costItem{
+				Service:     sharedResource,
+				Resource:    id,
+				Description: "EFS file system",
+				Usage:       fmt.Sprintf("$%g/GB-month", prices.EFSGBMonth),
+			})
+		case *logs.LogGroup:
+			items = append(items, costItem{
+				Service:     sharedResource,
+				Resource:    id,
+				Description: "CloudWatch logs",
+				Usage:       fmt.Sprintf("$%g/GB ingested, $%g/GB-month stored", prices.LogsIngestedGB, prices.LogsStoredGBMonth),
+			})
+		}
+	}
+	return items, nil
+}
+
+func estimateServiceCost(service types.ServiceConfig, template *cloudformation.Template, prices regionPrices) ([]costItem, error) {
+	taskDefinition := fmt.Sprintf("%sTaskDefinition", normalizeResourceName(service.Name))
+	definition, ok := template.Resources[taskDefinition].(*ecs.TaskDefinition)
+	if !ok {
+		return nil, nil
+	}
+	if !contains(definition.RequiresCompatibilities, ecsapi.LaunchTypeFargate) {
+		return []costItem{{
+			Service:     service.Name,
+			Resource:    taskDefinition,
+			Description: "EC2 capacity",
+			Usage:       "not estimated",
+		}}, nil
+	}
+
+	cpu, err := strconv.ParseFloat(definition.Cpu, 64)
+	if err != nil {
+		return nil, err
+	}
+	mem, err := strconv.ParseFloat(definition.Memory, 64)
+	if err != nil {
+		return nil, err
+	}
+	vcpu, gb := cpu/1024, mem/1024
+	hourly := vcpu*prices.FargateVCPUHour + gb*prices.FargateGBHour
+
+	if isScheduled(service) {
+		return []costItem{{
+			Service:     service.Name,
+			Resource:    taskDefinition,
+			Description: fmt.Sprintf("Fargate scheduled task, %g vCPU, %g GB", vcpu, gb),
+			Usage:       fmt.Sprintf("$%.4f/task-hour", hourly),
+		}}, nil
+	}
+
+	name := serviceResourceName(service.Name)
+	svc, ok := template.Resources[name].(*ecs.Service)
+	if !ok {
+		return nil, nil
+	}
+	spotHourly := vcpu*prices.FargateSpotVCPUHour + gb*prices.FargateSpotGBHour
+	monthly := func(tasks int) float64 {
+		spot := spotTasks(svc.CapacityProviderStrategy, tasks)
+		return (hourly*(float64(tasks)-spot) + spotHourly*spot) * hoursPerMonth
+	}
+
+	tasks, minTasks, maxTasks := fmt.Sprint(svc.DesiredCount), svc.DesiredCount, svc.DesiredCount
+	if target, ok := template.Resources[fmt.Sprintf("%sScalableTarget", normalizeResourceName(service.Name))].(*applicationautoscaling.ScalableTarget); ok {
+		tasks, minTasks, maxTasks = fmt.Sprintf("%d-%d", target.MinCapacity, target.MaxCapacity), target.MinCapacity, target.MaxCapacity
+	}
+	description := fmt.Sprintf("Fargate %s task(s), %g vCPU, %g GB", tasks, vcpu, gb)
+	if spotTasks(svc.CapacityProviderStrategy, maxTasks) > 0 {
+		description += ", with Spot"
+	}
+	items := []costItem{{
+		Service:     service.Name,
+		Resource:    name,
+		Description: description,
+		Monthly:     monthly(minTasks),
+		MonthlyMax:  monthly(maxTasks),
+	}}
+	network := svc.NetworkConfiguration
+	if network != nil && network.AwsvpcConfiguration != nil && network.AwsvpcConfiguration.AssignPublicIp == ecsapi.AssignPublicIpDisabled {
+		items = append(items, costItem{
+			Service:     service.Name,
+			Resource:    "NAT",
+			Description: "NAT gateway",
+			Usage:       fmt.Sprintf("$%g/hour per NAT gateway, $%g/GB", prices.NATHour, prices.NATGB),
+		})
+	}
+	return items, nil
+}
+
+// spotTasks returns the number of tasks, out of count, ECS places on Fargate Spot according to capacity provider
+// strategy. Tasks up to base are placed first, then remaining tasks are split by weight.
+func spotTasks(strategy []ecs.Service_CapacityProviderStrategyItem, count int) float64 {
+	var base, spotBase, weights, spotWeight int
+	for _, item := range strategy {
+		base += item.Base
+		weights += item.Weight
+		if item.CapacityProvider == fargateSpotCapacityProvider {
+			spotBase, spotWeight = item.Base, item.Weight
+		}
+	}
+	spot := float64(minInt(count, spotBase))
+	if remaining := count - minInt(count, base); remaining > 0 && weights > 0 {
+		spot += float64(remaining*spotWeight) / float64(weights)
+	}
+	return spot
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func printCostEstimate(w io.Writer, items []costItem, region string) error {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Service != items[j].Service {
+			// list shared resources last
+			if items[i].Service == sharedResource || items[j].Service == sharedResource {
+				return items[j].Service == sharedResource
+			}
+			return items[i].Service < items[j].Service
+		}
+		return items[i].Resource < items[j].Resource
+	})
+
+	var services []string
+	totals, totalsMax := map[string]float64{}, map[string]float64{}
+	var total, totalMax float64
+	err := formatter.PrintPrettySection(w, func(w io.Writer) {
+		for _, item := range items {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Service, item.Resource, item.Description, item.cost())
+			if _, ok := totals[item.Service]; !ok {
+				services = append(services, item.Service)
+			}
+			totals[item.Service] += item.Monthly
+			totalsMax[item.Service] += item.maxMonthly()
+			total += item.Monthly
+			totalMax += item.maxMonthly()
+		}
+	}, "SERVICE", "RESOURCE", "DESCRIPTION", "MONTHLY COST")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w)
+	if err != nil {
+		return err
+	}
+	err = formatter.PrintPrettySection(w, func(w io.Writer) {
+		for _, service := range services {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", service, formatMonthlyCost(totals[service], totalsMax[service]))
+		}
+		_, _ = fmt.Fprintf(w, "Total\t%s\n", formatMonthlyCost(total, totalMax))
+	}, "SERVICE", "MONTHLY COST")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\nEstimated with on-demand and indicative Fargate Spot prices for region %s, excluding usage-based costs\n", region)
+	return err
+}
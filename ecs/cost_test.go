@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestEstimateCost(t *testing.T) {
+	yaml := `
+services:
+  web:
+    image: nginx
+    ports:
+      - 80:80
+  worker:
+    image: worker
+    volumes:
+      - data:/data
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: '1'
+          memory: 2G
+volumes:
+  data:
+`
+	template := convertYaml(t, yaml, nil, useDefaultVPC, func(m *MockAPIMockRecorder) {
+		m.ListFileSystems(gomock.Any(), gomock.Any()).Return(nil, nil)
+	})
+	prices, err := getRegionPrices("us-east-1")
+	assert.NilError(t, err)
+	items, err := estimateTemplateCost(loadConfig(t, yaml), template, prices)
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, printCostEstimate(&out, items, "us-east-1"))
+	assert.Equal(t, out.String(), `SERVICE             RESOURCE            DESCRIPTION                            MONTHLY COST
+web                 WebService          Fargate 1 task(s), 0.25 vCPU, 0.5 GB   $9.01
+worker              WorkerService       Fargate 2 task(s), 1 vCPU, 2 GB        $72.08
+-                   DataFilesystem      EFS file system                        $0.3/GB-month
+-                   LoadBalancer        application load balancer              $16.43 + $0.008/LCU-hour
+-                   LogGroup            CloudWatch logs                        $0.5/GB ingested, $0.03/GB-month stored
+
+SERVICE             MONTHLY COST
+web                 $9.01
+worker              $72.08
+-                   $16.43
+Total               $97.52
+
+Estimated with on-demand and indicative Fargate Spot prices for region us-east-1, excluding usage-based costs
+`)
+}
+
+func TestEstimateCostSpotAndAutoscaling(t *testing.T) {
+	yaml := `
+services:
+  worker:
+    image: worker
+    x-aws-capacity:
+      spot: 3
+      ondemand: 1
+      base: 1
+    deploy:
+      x-aws-autoscaling:
+        min: 1
+        max: 5
+        cpu: 50
+`
+	template := convertYaml(t, yaml, nil, useDefaultVPC)
+	prices, err := getRegionPrices("us-east-1")
+	assert.NilError(t, err)
+	items, err := estimateTemplateCost(loadConfig(t, yaml), template, prices)
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, printCostEstimate(&out, items, "us-east-1"))
+	assert.Equal(t, out.String(), `SERVICE             RESOURCE            DESCRIPTION                                         MONTHLY COST
+worker              WorkerService       Fargate 1-5 task(s), 0.25 vCPU, 0.5 GB, with Spot   $9.01-$26.13
+-                   LogGroup            CloudWatch logs                                     $0.5/GB ingested, $0.03/GB-month stored
+
+SERVICE             MONTHLY COST
+worker              $9.01-$26.13
+-                   $0.00
+Total               $9.01-$26.13
+
+Estimated with on-demand and indicative Fargate Spot prices for region us-east-1, excluding usage-based costs
+`)
+}
+
+func TestSpotTasks(t *testing.T) {
+	strategy := []ecs.Service_CapacityProviderStrategyItem{
+		{CapacityProvider: "FARGATE", Base: 1, Weight: 1},
+		{CapacityProvider: "FARGATE_SPOT", Weight: 3},
+	}
+	assert.Equal(t, spotTasks(nil, 4), float64(0))
+	assert.Equal(t, spotTasks(strategy, 1), float64(0))
+	assert.Equal(t, spotTasks(strategy, 5), float64(3))
+	assert.Equal(t, spotTasks([]ecs.Service_CapacityProviderStrategyItem{{CapacityProvider: "FARGATE_SPOT", Base: 2, Weight: 1}}, 3), float64(3))
+}
+
+func TestEstimateCostUnknownRegion(t *testing.T) {
+	_, err := getRegionPrices("mars-north-1")
+	assert.Error(t, err, `no price data for region "mars-north-1"`)
+}
@@ -30,11 +30,13 @@ func (b *ecsAPIService) Down(ctx context.Context, projectName string, options ap
 		return err
 	}
 	return progress.Run(ctx, func(ctx context.Context) error {
-		return b.down(ctx, projectName)
+		return b.down(ctx, projectName, options.Volumes)
 	})
 }
 
-func (b *ecsAPIService) down(ctx context.Context, projectName string) error {
+// down deletes the application stack. EFS file systems created for volumes are retained by the stack, so they can be
+// re-attached to the application on next deployment, unless deleteVolumes is set
+func (b *ecsAPIService) down(ctx context.Context, projectName string, deleteVolumes bool) error {
 	resources, err := b.aws.ListStackResources(ctx, projectName)
 	if err != nil {
 		return err
@@ -59,7 +61,32 @@ func (b *ecsAPIService) down(ctx context.Context, projectName string) error {
 	if err != nil {
 		return err
 	}
-	return b.WaitStackCompletion(ctx, projectName, stackDelete, previousEvents...)
+	err = b.WaitStackCompletion(ctx, projectName, stackDelete, previousEvents...)
+	if err != nil || !deleteVolumes {
+		return err
+	}
+	return b.deleteVolumes(ctx, projectName)
+}
+
+// deleteVolumes deletes EFS file systems created for project volumes, identified by project tag. External volumes
+// are not tagged and as such are never deleted
+func (b *ecsAPIService) deleteVolumes(ctx context.Context, projectName string) error {
+	filesystems, err := b.aws.ListFileSystems(ctx, map[string]string{
+		api.ProjectLabel: projectName,
+	})
+	if err != nil {
+		return err
+	}
+	for _, fs := range filesystems {
+		err := doDelete(ctx, b.aws.DeleteFileSystem)(stackResource{
+			LogicalID: fs.ID(),
+			ARN:       fs.ID(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (b *ecsAPIService) previousStackEvents(ctx context.Context, project string) ([]string, error) {
@@ -94,7 +121,6 @@ func checkUnsupportedDownOptions(ctx context.Context, o api.DownOptions) error {
 		toCheck, expected interface{}
 		option            string
 	}{
-		{o.Volumes, false, "volumes"},
 		{o.Images, "", "images"},
 		{o.RemoveOrphans, false, "remove-orphans"},
 		{o.Timeout, nil, "timeout"},
@@ -0,0 +1,64 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func expectStackDeletion(m *MockAPIMockRecorder, project string) {
+	m.ListStackResources(gomock.Any(), project).Return(stackResources{}, nil)
+	m.DescribeStackEvents(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	m.DeleteStack(gomock.Any(), project).Return(nil)
+	m.GetStackID(gomock.Any(), project).Return("stack-id", nil)
+	m.WaitStackComplete(gomock.Any(), "stack-id", stackDelete).Return(nil)
+}
+
+func TestDownRetainsVolumes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	expectStackDeletion(m.EXPECT(), "test")
+
+	backend := &ecsAPIService{aws: m}
+	ctx := progress.WithContextWriter(context.TODO(), &recordingWriter{})
+	assert.NilError(t, backend.down(ctx, "test", false))
+}
+
+func TestDownDeletesVolumes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	expectStackDeletion(m.EXPECT(), "test")
+	m.EXPECT().ListFileSystems(gomock.Any(), map[string]string{api.ProjectLabel: "test"}).Return([]awsResource{
+		existingAWSResource{id: "fs-123"},
+	}, nil)
+	m.EXPECT().DeleteFileSystem(gomock.Any(), "fs-123").Return(nil)
+
+	backend := &ecsAPIService{aws: m}
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+	assert.NilError(t, backend.down(ctx, "test", true))
+	assert.Equal(t, w.events[len(w.events)-1].ID, "fs-123")
+	assert.Equal(t, w.events[len(w.events)-1].Status, progress.Done)
+}
@@ -0,0 +1,239 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/compose/v2/cmd/formatter"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/pkg/errors"
+	"github.com/sanathkr/go-yaml"
+)
+
+func isDiff(project *types.Project) bool {
+	diff, ok := project.Extensions[ExtensionDiff].(bool)
+	return ok && diff
+}
+
+// templateChange describes a resource which differs between the template rendered from compose model and the
+// deployed one
+type templateChange struct {
+	Action     string
+	LogicalID  string
+	Type       string
+	Properties []string
+}
+
+// diff reports differences between the compose model and the deployed stack template, then between this template
+// and live resources as detected by CloudFormation drift detection
+func (b *ecsAPIService) diff(ctx context.Context, project *types.Project) ([]byte, error) {
+	exists, err := b.aws.StackExists(ctx, project.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.Wrapf(api.ErrNotFound, "stack %q doesn't exist", project.Name)
+	}
+
+	rendered, err := b.render(ctx, project, "yaml")
+	if err != nil {
+		return nil, err
+	}
+	deployed, err := b.aws.GetStackTemplate(ctx, project.Name)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := compareTemplates(deployed, rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts, err := b.aws.DetectStackDrift(ctx, project.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := printTemplateChanges(&out, changes); err != nil {
+		return nil, err
+	}
+	if err := printResourceDrifts(&out, drifts); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+type templateResource struct {
+	Type string                 `yaml:"Type"`
+	Body map[string]interface{} `yaml:",inline"`
+}
+
+// compareTemplates computes resource-level changes to apply on deployed template to get rendered one. Modified
+// resources report the top-level attributes and properties which differ
+func compareTemplates(deployed []byte, rendered []byte) ([]templateChange, error) {
+	var before, after struct {
+		Resources map[string]templateResource `yaml:"Resources"`
+	}
+	if err := yaml.Unmarshal(deployed, &before); err != nil {
+		return nil, errors.Wrap(err, "failed to parse deployed template")
+	}
+	if err := yaml.Unmarshal(rendered, &after); err != nil {
+		return nil, err
+	}
+
+	var changes []templateChange
+	for id, resource := range after.Resources {
+		previous, ok := before.Resources[id]
+		if !ok {
+			changes = append(changes, templateChange{
+				Action:    cloudformation.ChangeActionAdd,
+				LogicalID: id,
+				Type:      resource.Type,
+			})
+			continue
+		}
+		if previous.Type != resource.Type {
+			changes = append(changes, templateChange{
+				Action:     cloudformation.ChangeActionModify,
+				LogicalID:  id,
+				Type:       resource.Type,
+				Properties: []string{"Type"},
+			})
+			continue
+		}
+		properties := diffAttributes(previous.Body, resource.Body)
+		if len(properties) > 0 {
+			changes = append(changes, templateChange{
+				Action:     cloudformation.ChangeActionModify,
+				LogicalID:  id,
+				Type:       resource.Type,
+				Properties: properties,
+			})
+		}
+	}
+	for id, resource := range before.Resources {
+		if _, ok := after.Resources[id]; !ok {
+			changes = append(changes, templateChange{
+				Action:    cloudformation.ChangeActionRemove,
+				LogicalID: id,
+				Type:      resource.Type,
+			})
+		}
+	}
+	return changes, nil
+}
+
+// diffAttributes lists resource attributes which differ, using `Properties.<name>` for resource properties
+func diffAttributes(before map[string]interface{}, after map[string]interface{}) []string {
+	var diff []string
+	for _, key := range unionKeys(before, after) {
+		if key != "Properties" {
+			if !reflect.DeepEqual(before[key], after[key]) {
+				diff = append(diff, key)
+			}
+			continue
+		}
+		b, _ := before[key].(map[interface{}]interface{})
+		a, _ := after[key].(map[interface{}]interface{})
+		properties := map[string]bool{}
+		for k := range b {
+			properties[fmt.Sprint(k)] = true
+		}
+		for k := range a {
+			properties[fmt.Sprint(k)] = true
+		}
+		var names []string
+		for k := range properties {
+			if !reflect.DeepEqual(b[k], a[k]) {
+				names = append(names, "Properties."+k)
+			}
+		}
+		sort.Strings(names)
+		diff = append(diff, names...)
+	}
+	return diff
+}
+
+func unionKeys(before map[string]interface{}, after map[string]interface{}) []string {
+	keys := map[string]bool{}
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	var union []string
+	for k := range keys {
+		union = append(union, k)
+	}
+	sort.Strings(union)
+	return union
+}
+
+func printTemplateChanges(w io.Writer, changes []templateChange) error {
+	_, err := fmt.Fprintln(w, "Compose file changes to the deployed template:")
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes")
+		return err
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].LogicalID < changes[j].LogicalID
+	})
+	return formatter.PrintPrettySection(w, func(w io.Writer) {
+		for _, c := range changes {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Action, c.LogicalID, c.Type, joinOrDash(c.Properties))
+		}
+	}, "ACTION", "RESOURCE", "TYPE", "PROPERTIES")
+}
+
+func printResourceDrifts(w io.Writer, drifts []resourceDrift) error {
+	_, err := fmt.Fprintln(w, "\nLive resources drifted from the deployed template:")
+	if err != nil {
+		return err
+	}
+	if len(drifts) == 0 {
+		_, err := fmt.Fprintln(w, "No drift")
+		return err
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].LogicalID < drifts[j].LogicalID
+	})
+	return formatter.PrintPrettySection(w, func(w io.Writer) {
+		for _, d := range drifts {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Status, d.LogicalID, d.Type, joinOrDash(d.Properties))
+		}
+	}, "DRIFT", "RESOURCE", "TYPE", "PROPERTIES")
+}
+
+func joinOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,106 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestCompareTemplates(t *testing.T) {
+	deployed := `
+Resources:
+  Cluster:
+    Type: AWS::ECS::Cluster
+  FooService:
+    Type: AWS::ECS::Service
+    DependsOn:
+      - FooListener
+    Properties:
+      DesiredCount: 1
+      Cluster:
+        Ref: Cluster
+  BarService:
+    Type: AWS::ECS::Service
+`
+	rendered := `
+Resources:
+  Cluster:
+    Type: AWS::ECS::Cluster
+  FooService:
+    Type: AWS::ECS::Service
+    Properties:
+      DesiredCount: 2
+      Cluster:
+        Ref: Cluster
+      PropagateTags: SERVICE
+  ZotService:
+    Type: AWS::ECS::Service
+`
+	changes, err := compareTemplates([]byte(deployed), []byte(rendered))
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, printTemplateChanges(&out, changes))
+	assert.Equal(t, out.String(), `Compose file changes to the deployed template:
+ACTION              RESOURCE            TYPE                PROPERTIES
+Remove              BarService          AWS::ECS::Service   -
+Modify              FooService          AWS::ECS::Service   DependsOn,Properties.DesiredCount,Properties.PropagateTags
+Add                 ZotService          AWS::ECS::Service   -
+`)
+}
+
+func TestDiff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+
+	project := loadConfig(t, `
+services:
+  foo:
+    image: hello_world@sha256:e0c3d3ba6b5b1b8ab6e9e3d3cf1d7d0e64d4d5c3c3e1c38c1cc1b6b2ab1e9a3d
+`)
+	backend := &ecsAPIService{aws: m}
+	useDefaultVPC(m.EXPECT())
+	deployed, err := backend.render(context.TODO(), project, "yaml")
+	assert.NilError(t, err)
+
+	project.Extensions = map[string]interface{}{ExtensionDiff: true}
+	useDefaultVPC(m.EXPECT())
+	m.EXPECT().StackExists(gomock.Any(), t.Name()).Return(true, nil)
+	m.EXPECT().GetStackTemplate(gomock.Any(), t.Name()).Return(deployed, nil)
+	m.EXPECT().DetectStackDrift(gomock.Any(), t.Name()).Return([]resourceDrift{
+		{LogicalID: "FooService", Type: "AWS::ECS::Service", Status: "MODIFIED", Properties: []string{"/DesiredCount"}},
+		{LogicalID: "LoadBalancer", Type: "AWS::ElasticLoadBalancingV2::LoadBalancer", Status: "DELETED"},
+	}, nil)
+
+	out, err := backend.Convert(context.TODO(), project, api.ConvertOptions{Format: "yaml"})
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), `Compose file changes to the deployed template:
+No changes
+
+Live resources drifted from the deployed template:
+DRIFT               RESOURCE            TYPE                                        PROPERTIES
+MODIFIED            FooService          AWS::ECS::Service                           /DesiredCount
+DELETED             LoadBalancer        AWS::ElasticLoadBalancingV2::LoadBalancer   -
+`)
+}
@@ -20,6 +20,8 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/awslabs/goformation/v4/cloudformation"
@@ -34,67 +36,138 @@ const (
 	placementConstraintMachine = "node.machine == "
 )
 
-func (b *ecsAPIService) createCapacityProvider(ctx context.Context, project *types.Project, template *cloudformation.Template, resources awsResources) error {
-	var (
-		ec2         bool
-		ami         string
-		machineType string
-	)
-	for _, service := range project.Services {
-		if requireEC2(service) {
-			ec2 = true
-			// TODO once we can assign a service to a CapacityProvider, we could run this _per service_
-			ami, machineType = getUserDefinedMachine(service)
-			break
+const (
+	defaultMinInstances = 1
+	defaultMaxInstances = 10
+
+	recommendedAMIParameter    = "/aws/service/ecs/optimized-ami/amazon-linux-2/recommended"
+	recommendedGPUAMIParameter = "/aws/service/ecs/optimized-ami/amazon-linux-2/gpu/recommended"
+)
+
+// capacityProvider describes EC2 instances to run services sharing the same machine requirements
+type capacityProvider struct {
+	name        string
+	ami         string
+	machineType string
+	gpu         bool
+	min         int
+	max         int
+}
+
+// getMachine returns the machine type and AMI to run service on EC2, the latter being empty when the ECS recommended
+// AMI is to be used
+func getMachine(service types.ServiceConfig) (string, string, error) {
+	ami, machineType := getUserDefinedMachine(service)
+	if machineType == "" {
+		t, err := guessMachineType(service)
+		if err != nil {
+			return "", "", err
 		}
+		machineType = t
 	}
+	return machineType, ami, nil
+}
 
-	if !ec2 {
-		return nil
+func capacityProviderResourceName(service types.ServiceConfig) (string, error) {
+	machineType, ami, err := getMachine(service)
+	if err != nil {
+		return "", err
 	}
+	return normalizeResourceName(machineType + ami), nil
+}
 
-	if ami == "" {
-		recommended, err := b.aws.GetParameter(ctx, "/aws/service/ecs/optimized-ami/amazon-linux-2/gpu/recommended")
-		if err != nil {
-			return err
+// getInstancesLimits returns min and max instances set by service, or -1 when not set
+func getInstancesLimits(service types.ServiceConfig) (int, int, error) {
+	limits := []int{-1, -1}
+	for i, x := range []string{extensionMinInstances, extensionMaxInstances} {
+		v, ok := service.Extensions[x]
+		if !ok {
+			continue
 		}
-		ami = recommended
+		limit, ok := v.(int)
+		if !ok || limit < 0 {
+			return 0, 0, fmt.Errorf("service %s: %s must be a positive integer", service.Name, x)
+		}
+		limits[i] = limit
 	}
+	return limits[0], limits[1], nil
+}
 
-	if machineType == "" {
-		t, err := guessMachineType(project)
+// getCapacityProviders groups services running on EC2 by machine requirements. Services sharing a capacity provider
+// get the autoscaling group sized to match the highest min and max instances they require
+func getCapacityProviders(project *types.Project) ([]*capacityProvider, error) {
+	var providers []*capacityProvider
+	byName := map[string]*capacityProvider{}
+	for _, service := range project.Services {
+		if !requireEC2(service) {
+			continue
+		}
+		machineType, ami, err := getMachine(service)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		min, max, err := getInstancesLimits(service)
+		if err != nil {
+			return nil, err
+		}
+		requirements, err := toResourceRequirements(service)
+		if err != nil {
+			return nil, err
+		}
+		name := normalizeResourceName(machineType + ami)
+		provider, ok := byName[name]
+		if !ok {
+			provider = &capacityProvider{
+				name:        name,
+				ami:         ami,
+				machineType: machineType,
+				min:         -1,
+				max:         -1,
+			}
+			byName[name] = provider
+			providers = append(providers, provider)
+		}
+		if requirements != nil && requirements.gpus > 0 {
+			// GPU services need the ECS GPU-optimized AMI, whatever the machine type they run on
+			provider.gpu = true
+		}
+		if min > provider.min {
+			provider.min = min
+		}
+		if max > provider.max {
+			provider.max = max
 		}
-		machineType = t
 	}
 
-	template.Resources["CapacityProvider"] = &ecs.CapacityProvider{
-		AutoScalingGroupProvider: &ecs.CapacityProvider_AutoScalingGroupProvider{
-			AutoScalingGroupArn: cloudformation.Ref("AutoscalingGroup"),
-			ManagedScaling: &ecs.CapacityProvider_ManagedScaling{
-				TargetCapacity: 100,
-			},
-		},
-		Tags: projectTags(project),
+	for _, provider := range providers {
+		if provider.min < 0 {
+			provider.min = defaultMinInstances
+		}
+		if provider.max < 0 {
+			provider.max = defaultMaxInstances
+		}
+		if provider.min > provider.max {
+			return nil, fmt.Errorf("services running on %s instances: %s can't be greater than %s", provider.machineType, extensionMinInstances, extensionMaxInstances)
+		}
 	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].name < providers[j].name
+	})
+	return providers, nil
+}
 
-	template.Resources["AutoscalingGroup"] = &autoscaling.AutoScalingGroup{
-		LaunchConfigurationName: cloudformation.Ref("LaunchConfiguration"),
-		MaxSize:                 "10", //TODO
-		MinSize:                 "1",
-		VPCZoneIdentifier:       resources.subnetsIDs(),
+func (b *ecsAPIService) createCapacityProviders(ctx context.Context, project *types.Project, template *cloudformation.Template, resources awsResources) error {
+	providers, err := getCapacityProviders(project)
+	if err != nil {
+		return err
+	}
+	if len(providers) == 0 {
+		return nil
 	}
 
-	userData := base64.StdEncoding.EncodeToString([]byte(
-		fmt.Sprintf("#!/bin/bash\necho ECS_CLUSTER=%s >> /etc/ecs/ecs.config", project.Name)))
-
-	template.Resources["LaunchConfiguration"] = &autoscaling.LaunchConfiguration{
-		ImageId:            ami,
-		InstanceType:       machineType,
-		SecurityGroups:     resources.allSecurityGroups(),
-		IamInstanceProfile: cloudformation.Ref("EC2InstanceProfile"),
-		UserData:           userData,
+	cluster, ok := template.Resources["Cluster"].(*ecs.Cluster)
+	if !ok {
+		return fmt.Errorf("services running on EC2 require the cluster to be created by compose, can't be used with %s", extensionCluster)
 	}
 
 	template.Resources["EC2InstanceProfile"] = &iam.InstanceProfile{
@@ -109,11 +182,55 @@ func (b *ecsAPIService) createCapacityProvider(ctx context.Context, project *typ
 		Tags: projectTags(project),
 	}
 
-	cluster := template.Resources["Cluster"].(*ecs.Cluster)
-	cluster.CapacityProviders = []string{
-		cloudformation.Ref("CapacityProvider"),
-	}
+	userData := base64.StdEncoding.EncodeToString([]byte(
+		fmt.Sprintf("#!/bin/bash\necho ECS_CLUSTER=%s >> /etc/ecs/ecs.config", project.Name)))
+
+	for _, provider := range providers {
+		ami := provider.ami
+		if ami == "" {
+			parameter := recommendedAMIParameter
+			if provider.gpu {
+				parameter = recommendedGPUAMIParameter
+			}
+			recommended, err := b.aws.GetParameter(ctx, parameter)
+			if err != nil {
+				return err
+			}
+			ami = recommended
+		}
+
+		capacityProvider := fmt.Sprintf("%sCapacityProvider", provider.name)
+		autoscalingGroup := fmt.Sprintf("%sAutoscalingGroup", provider.name)
+		launchConfiguration := fmt.Sprintf("%sLaunchConfiguration", provider.name)
+
+		template.Resources[capacityProvider] = &ecs.CapacityProvider{
+			AutoScalingGroupProvider: &ecs.CapacityProvider_AutoScalingGroupProvider{
+				AutoScalingGroupArn: cloudformation.Ref(autoscalingGroup),
+				ManagedScaling: &ecs.CapacityProvider_ManagedScaling{
+					Status:         "ENABLED",
+					TargetCapacity: 100,
+				},
+			},
+			Tags: projectTags(project),
+		}
+
+		template.Resources[autoscalingGroup] = &autoscaling.AutoScalingGroup{
+			LaunchConfigurationName: cloudformation.Ref(launchConfiguration),
+			MaxSize:                 strconv.Itoa(provider.max),
+			MinSize:                 strconv.Itoa(provider.min),
+			VPCZoneIdentifier:       resources.subnetsIDs(),
+		}
 
+		template.Resources[launchConfiguration] = &autoscaling.LaunchConfiguration{
+			ImageId:            ami,
+			InstanceType:       provider.machineType,
+			SecurityGroups:     resources.allSecurityGroups(),
+			IamInstanceProfile: cloudformation.Ref("EC2InstanceProfile"),
+			UserData:           userData,
+		}
+
+		cluster.CapacityProviders = append(cluster.CapacityProviders, cloudformation.Ref(capacityProvider))
+	}
 	return nil
 }
 
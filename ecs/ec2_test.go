@@ -17,9 +17,13 @@
 package ecs
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/awslabs/goformation/v4/cloudformation"
 	"github.com/awslabs/goformation/v4/cloudformation/autoscaling"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/golang/mock/gomock"
 	"gotest.tools/v3/assert"
 )
 
@@ -43,7 +47,191 @@ services:
                 kind: gpus
                 value: 1                    
 `, nil, useDefaultVPC)
-	lc := template.Resources["LaunchConfiguration"].(*autoscaling.LaunchConfiguration)
+	lc := template.Resources["T0femtoami123456789LaunchConfiguration"].(*autoscaling.LaunchConfiguration)
 	assert.Check(t, lc.ImageId == "ami123456789")
 	assert.Check(t, lc.InstanceType == "t0.femto")
 }
+
+func TestGPUAMIForUserDefinedMachine(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  learning:
+    image: "image"
+    deploy:
+      placement:
+        constraints:
+          - "node.machine == p3.2xlarge"
+      resources:
+        reservations:
+          devices:
+            - capabilities: ["gpu"]
+`, nil, useDefaultVPC, func(m *MockAPIMockRecorder) {
+		m.GetParameter(gomock.Any(), recommendedGPUAMIParameter).Return("ami-gpu", nil)
+	})
+	lc := template.Resources["P32xlargeLaunchConfiguration"].(*autoscaling.LaunchConfiguration)
+	assert.Equal(t, lc.ImageId, "ami-gpu")
+	assert.Equal(t, lc.InstanceType, "p3.2xlarge")
+}
+
+func TestCapacityProviderPerMachine(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  learning:
+    image: "image"
+    deploy:
+      resources:
+        reservations:
+          generic_resources:
+            - discrete_resource_spec:
+                kind: gpus
+                value: 1
+    x-aws-max_instances: 4
+  training:
+    image: "image"
+    deploy:
+      resources:
+        reservations:
+          generic_resources:
+            - discrete_resource_spec:
+                kind: gpus
+                value: 1
+    x-aws-min_instances: 2
+  worker:
+    image: "image"
+    deploy:
+      placement:
+        constraints:
+          - "node.machine == m5.large"
+  front:
+    image: "image"
+`, nil, useDefaultVPC, func(m *MockAPIMockRecorder) {
+		m.GetParameter(gomock.Any(), recommendedGPUAMIParameter).Return("ami-gpu", nil)
+		m.GetParameter(gomock.Any(), recommendedAMIParameter).Return("ami-cpu", nil)
+	})
+
+	lc := template.Resources["G4dnxlargeLaunchConfiguration"].(*autoscaling.LaunchConfiguration)
+	assert.Equal(t, lc.ImageId, "ami-gpu")
+	asg := template.Resources["G4dnxlargeAutoscalingGroup"].(*autoscaling.AutoScalingGroup)
+	assert.Equal(t, asg.MinSize, "2")
+	assert.Equal(t, asg.MaxSize, "4")
+
+	lc = template.Resources["M5largeLaunchConfiguration"].(*autoscaling.LaunchConfiguration)
+	assert.Equal(t, lc.ImageId, "ami-cpu")
+	assert.Equal(t, lc.InstanceType, "m5.large")
+	asg = template.Resources["M5largeAutoscalingGroup"].(*autoscaling.AutoScalingGroup)
+	assert.Equal(t, asg.MinSize, "1")
+	assert.Equal(t, asg.MaxSize, "10")
+
+	cluster := template.Resources["Cluster"].(*ecs.Cluster)
+	assert.DeepEqual(t, cluster.CapacityProviders, []string{
+		cloudformation.Ref("G4dnxlargeCapacityProvider"),
+		cloudformation.Ref("M5largeCapacityProvider"),
+	})
+
+	for name, provider := range map[string]string{
+		"LearningService": "G4dnxlargeCapacityProvider",
+		"TrainingService": "G4dnxlargeCapacityProvider",
+		"WorkerService":   "M5largeCapacityProvider",
+	} {
+		service := template.Resources[name].(*ecs.Service)
+		assert.Equal(t, service.LaunchType, "")
+		assert.DeepEqual(t, service.CapacityProviderStrategy, []ecs.Service_CapacityProviderStrategyItem{
+			{CapacityProvider: cloudformation.Ref(provider), Weight: 1},
+		})
+	}
+	front := template.Resources["FrontService"].(*ecs.Service)
+	assert.Equal(t, front.LaunchType, "FARGATE")
+	assert.Check(t, front.CapacityProviderStrategy == nil)
+}
+
+func TestCapacityProviderInvalidInstancesLimits(t *testing.T) {
+	convertYaml(t, `
+services:
+  worker:
+    image: "image"
+    deploy:
+      placement:
+        constraints:
+          - "node.machine == m5.large"
+    x-aws-min_instances: 5
+    x-aws-max_instances: 2
+`, errors.New("services running on m5.large instances: x-aws-min_instances can't be greater than x-aws-max_instances"), useDefaultVPC)
+}
+
+func TestBindMountsAndTmpfsOnEC2(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  worker:
+    image: "image"
+    deploy:
+      placement:
+        constraints:
+          - "node.machine == m5.large"
+    tmpfs:
+      - /run
+    volumes:
+      - /var/lib/docker/data:/data:ro
+      - type: tmpfs
+        target: /cache
+        tmpfs:
+          size: 64m
+`, nil, useDefaultVPC, useGPU)
+	def := template.Resources["WorkerTaskDefinition"].(*ecs.TaskDefinition)
+	assert.DeepEqual(t, def.Volumes, []ecs.TaskDefinition_Volume{
+		{
+			Host: &ecs.TaskDefinition_HostVolumeProperties{SourcePath: "/var/lib/docker/data"},
+			Name: "hostVarlibdockerdata_0",
+		},
+	})
+	main := getMainContainer(def, t)
+	assert.DeepEqual(t, main.MountPoints, []ecs.TaskDefinition_MountPoint{
+		{ContainerPath: "/data", ReadOnly: true, SourceVolume: "hostVarlibdockerdata_0"},
+	})
+	assert.DeepEqual(t, main.LinuxParameters.Tmpfs, []ecs.TaskDefinition_Tmpfs{
+		{ContainerPath: "/run", Size: 100},
+		{ContainerPath: "/cache", Size: 64},
+	})
+}
+
+func TestBindMountsSharedSource(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  worker:
+    image: "image"
+    deploy:
+      placement:
+        constraints:
+          - "node.machine == m5.large"
+    volumes:
+      - /var/log:/logs:ro
+      - /var/log:/var/log
+      - /varlog:/other
+`, nil, useDefaultVPC, useGPU)
+	def := template.Resources["WorkerTaskDefinition"].(*ecs.TaskDefinition)
+	assert.DeepEqual(t, def.Volumes, []ecs.TaskDefinition_Volume{
+		{
+			Host: &ecs.TaskDefinition_HostVolumeProperties{SourcePath: "/var/log"},
+			Name: "hostVarlog_0",
+		},
+		{
+			Host: &ecs.TaskDefinition_HostVolumeProperties{SourcePath: "/varlog"},
+			Name: "hostVarlog_1",
+		},
+	})
+	main := getMainContainer(def, t)
+	assert.DeepEqual(t, main.MountPoints, []ecs.TaskDefinition_MountPoint{
+		{ContainerPath: "/logs", ReadOnly: true, SourceVolume: "hostVarlog_0"},
+		{ContainerPath: "/var/log", ReadOnly: false, SourceVolume: "hostVarlog_0"},
+		{ContainerPath: "/other", ReadOnly: false, SourceVolume: "hostVarlog_1"},
+	})
+}
+
+func TestBindMountsOnFargate(t *testing.T) {
+	convertYaml(t, `
+services:
+  worker:
+    image: "image"
+    volumes:
+      - /var/lib/docker/data:/data
+`, errors.New("ECS Fargate does not support bind mounts from host: incompatible attribute"))
+}
@@ -0,0 +1,227 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/compose-spec/compose-go/types"
+	cliconfig "github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	clitypes "github.com/docker/cli/cli/config/types"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/compose"
+	"github.com/docker/docker/client"
+)
+
+const ecrImageTag = "latest"
+
+// ecrImagePlatform is the platform images are built for, as ECS tasks run on linux/amd64
+const ecrImagePlatform = "linux/amd64"
+
+// getECRRepository returns the ECR repository name service image is pushed to. Services with a build section and no
+// image use <project>/<service> by default, x-aws-ecr can set another repository name, or disable ECR with `false`
+func getECRRepository(project *types.Project, service types.ServiceConfig) (string, error) {
+	x, ok := service.Extensions[extensionECR]
+	if !ok {
+		if service.Build == nil || service.Image != "" {
+			return "", nil
+		}
+		return strings.ToLower(fmt.Sprintf("%s/%s", project.Name, service.Name)), nil
+	}
+	switch v := x.(type) {
+	case bool:
+		if !v {
+			return "", nil
+		}
+		if service.Build == nil {
+			return "", fmt.Errorf("service %s: %s requires a build section", service.Name, extensionECR)
+		}
+		return strings.ToLower(fmt.Sprintf("%s/%s", project.Name, service.Name)), nil
+	case string:
+		if service.Build == nil {
+			return "", fmt.Errorf("service %s: %s requires a build section", service.Name, extensionECR)
+		}
+		return v, nil
+	default:
+		return "", fmt.Errorf("service %s: %s must be a repository name or a boolean", service.Name, extensionECR)
+	}
+}
+
+// resolveECRImages ensures ECR repositories exist for services to be built, and set those services image accordingly.
+// Returns the names of services to be built, and the repository their image is pushed to.
+func (b *ecsAPIService) resolveECRImages(ctx context.Context, project *types.Project) (map[string]string, error) {
+	repositories := map[string]string{}
+	for i, service := range project.Services {
+		repository, err := getECRRepository(project, service)
+		if err != nil {
+			return nil, err
+		}
+		if repository == "" {
+			continue
+		}
+		uri, err := b.aws.ResolveRepository(ctx, repository, map[string]string{
+			api.ProjectLabel: project.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		project.Services[i].Image = fmt.Sprintf("%s:%s", uri, ecrImageTag)
+		repositories[service.Name] = repository
+	}
+	return repositories, nil
+}
+
+// resolveECRImageNames sets services image to their ECR repository, without creating repositories nor building images,
+// so that a template can be rendered for images which have not been pushed yet. Images already pushed to the
+// repository are left unchanged.
+func (b *ecsAPIService) resolveECRImageNames(ctx context.Context, project *types.Project) error {
+	var registry string
+	for i, service := range project.Services {
+		repository, err := getECRRepository(project, service)
+		if err != nil {
+			return err
+		}
+		if repository == "" {
+			continue
+		}
+		if registry == "" {
+			auth, err := b.aws.GetRegistryCredentials(ctx)
+			if err != nil {
+				return err
+			}
+			registry = auth.ServerAddress
+		}
+		uri := fmt.Sprintf("%s/%s", registry, repository)
+		if strings.HasPrefix(service.Image, uri+":") || strings.HasPrefix(service.Image, uri+"@") {
+			continue
+		}
+		project.Services[i].Image = fmt.Sprintf("%s:%s", uri, ecrImageTag)
+	}
+	return nil
+}
+
+// pinECRImages replaces services image tag by the digest of the image pushed to ECR, so that task definition
+// is updated for each new image
+func (b *ecsAPIService) pinECRImages(ctx context.Context, project *types.Project, repositories map[string]string) error {
+	for i, service := range project.Services {
+		repository, ok := repositories[service.Name]
+		if !ok {
+			continue
+		}
+		digest, err := b.aws.GetImageDigest(ctx, repository, ecrImageTag)
+		if err != nil {
+			return err
+		}
+		project.Services[i].Image = fmt.Sprintf("%s@%s", strings.TrimSuffix(service.Image, ":"+ecrImageTag), digest)
+	}
+	return nil
+}
+
+// buildAndPushImages builds services images with the local Docker engine, then pushes them to ECR
+func (b *ecsAPIService) buildAndPushImages(ctx context.Context, project *types.Project) error {
+	repositories, err := b.resolveECRImages(ctx, project)
+	if err != nil || len(repositories) == 0 {
+		return err
+	}
+	if err := b.buildImages(ctx, project, repositories, api.BuildOptions{}); err != nil {
+		return err
+	}
+	if err := b.pushImages(ctx, project, repositories, api.PushOptions{}); err != nil {
+		return err
+	}
+	return b.pinECRImages(ctx, project, repositories)
+}
+
+// Build builds images for services pushed to ECR with the local Docker engine
+func (b *ecsAPIService) Build(ctx context.Context, project *types.Project, options api.BuildOptions) error {
+	repositories, err := b.resolveECRImages(ctx, project)
+	if err != nil || len(repositories) == 0 {
+		return err
+	}
+	return b.buildImages(ctx, project, repositories, options)
+}
+
+// Push pushes images for services with a build section to ECR
+func (b *ecsAPIService) Push(ctx context.Context, project *types.Project, options api.PushOptions) error {
+	repositories, err := b.resolveECRImages(ctx, project)
+	if err != nil || len(repositories) == 0 {
+		return err
+	}
+	return b.pushImages(ctx, project, repositories, options)
+}
+
+func (b *ecsAPIService) buildImages(ctx context.Context, project *types.Project, repositories map[string]string, options api.BuildOptions) error {
+	engine, err := localEngine(cliconfig.LoadDefaultConfigFile(os.Stderr))
+	if err != nil {
+		return err
+	}
+	ecrProject := *project
+	ecrProject.Services = nil
+	var services []string
+	for _, service := range project.Services {
+		if _, ok := repositories[service.Name]; ok && (len(options.Services) == 0 || contains(options.Services, service.Name)) {
+			switch service.Platform {
+			case "":
+				service.Platform = ecrImagePlatform
+			case ecrImagePlatform:
+			default:
+				return fmt.Errorf("service %s: platform %s is not supported, images must be built for %s", service.Name, service.Platform, ecrImagePlatform)
+			}
+			services = append(services, service.Name)
+		}
+		ecrProject.Services = append(ecrProject.Services, service)
+	}
+	options.Services = services
+	return engine.Build(ctx, &ecrProject, options)
+}
+
+// pushImages pushes images to ECR, using a registry authorization token so user doesn't need to log in
+func (b *ecsAPIService) pushImages(ctx context.Context, project *types.Project, repositories map[string]string, options api.PushOptions) error {
+	auth, err := b.aws.GetRegistryCredentials(ctx)
+	if err != nil {
+		return err
+	}
+	configFile := configfile.New("")
+	configFile.AuthConfigs = map[string]clitypes.AuthConfig{
+		auth.ServerAddress: auth,
+	}
+	engine, err := localEngine(configFile)
+	if err != nil {
+		return err
+	}
+
+	ecrProject := *project
+	ecrProject.Services = nil
+	for _, service := range project.Services {
+		if _, ok := repositories[service.Name]; ok {
+			ecrProject.Services = append(ecrProject.Services, service)
+		}
+	}
+	return engine.Push(ctx, &ecrProject, options)
+}
+
+func localEngine(configFile *configfile.ConfigFile) (api.Service, error) {
+	apiClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	return compose.NewComposeService(apiClient, configFile), nil
+}
@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"testing"
+
+	clitypes "github.com/docker/cli/cli/config/types"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestResolveECRImages(t *testing.T) {
+	project := loadConfig(t, `
+services:
+  api:
+    build: .
+  worker:
+    build: .
+    x-aws-ecr: mycompany/worker
+  local:
+    build: .
+    image: local
+    x-aws-ecr: false
+  published:
+    build: .
+    image: mycompany/published
+  db:
+    image: postgres
+`)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	tags := map[string]string{"com.docker.compose.project": "TestResolveECRImages"}
+	m.EXPECT().ResolveRepository(gomock.Any(), "testresolveecrimages/api", tags).Return("012345678910.dkr.ecr.eu-west-3.amazonaws.com/testresolveecrimages/api", nil)
+	m.EXPECT().ResolveRepository(gomock.Any(), "mycompany/worker", tags).Return("012345678910.dkr.ecr.eu-west-3.amazonaws.com/mycompany/worker", nil)
+	m.EXPECT().GetImageDigest(gomock.Any(), "testresolveecrimages/api", "latest").Return("sha256:aaaa", nil)
+	m.EXPECT().GetImageDigest(gomock.Any(), "mycompany/worker", "latest").Return("sha256:bbbb", nil)
+
+	backend := &ecsAPIService{aws: m}
+	repositories, err := backend.resolveECRImages(context.TODO(), project)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, repositories, map[string]string{
+		"api":    "testresolveecrimages/api",
+		"worker": "mycompany/worker",
+	})
+	api, _ := project.GetService("api")
+	assert.Equal(t, api.Image, "012345678910.dkr.ecr.eu-west-3.amazonaws.com/testresolveecrimages/api:latest")
+
+	err = backend.pinECRImages(context.TODO(), project, repositories)
+	assert.NilError(t, err)
+	images := map[string]string{}
+	for _, s := range project.Services {
+		images[s.Name] = s.Image
+	}
+	assert.DeepEqual(t, images, map[string]string{
+		"api":       "012345678910.dkr.ecr.eu-west-3.amazonaws.com/testresolveecrimages/api@sha256:aaaa",
+		"worker":    "012345678910.dkr.ecr.eu-west-3.amazonaws.com/mycompany/worker@sha256:bbbb",
+		"local":     "local",
+		"published": "mycompany/published",
+		"db":        "postgres",
+	})
+}
+
+func TestECRRequiresBuild(t *testing.T) {
+	project := loadConfig(t, `
+services:
+  db:
+    image: postgres
+    x-aws-ecr: true
+`)
+	_, err := getECRRepository(project, project.Services[0])
+	assert.Error(t, err, "service db: x-aws-ecr requires a build section")
+}
+
+func TestResolveECRImageNames(t *testing.T) {
+	project := loadConfig(t, `
+services:
+  api:
+    build: .
+  worker:
+    build: .
+    x-aws-ecr: mycompany/worker
+  db:
+    image: postgres
+`)
+	for i, s := range project.Services {
+		if s.Name == "worker" {
+			project.Services[i].Image = "012345678910.dkr.ecr.eu-west-3.amazonaws.com/mycompany/worker@sha256:bbbb"
+		}
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	m.EXPECT().GetRegistryCredentials(gomock.Any()).Return(clitypes.AuthConfig{
+		ServerAddress: "012345678910.dkr.ecr.eu-west-3.amazonaws.com",
+	}, nil)
+
+	backend := &ecsAPIService{aws: m}
+	assert.NilError(t, backend.resolveECRImageNames(context.TODO(), project))
+	images := map[string]string{}
+	for _, s := range project.Services {
+		images[s.Name] = s.Image
+	}
+	assert.DeepEqual(t, images, map[string]string{
+		"api":    "012345678910.dkr.ecr.eu-west-3.amazonaws.com/testresolveecrimagenames/api:latest",
+		"worker": "012345678910.dkr.ecr.eu-west-3.amazonaws.com/mycompany/worker@sha256:bbbb",
+		"db":     "postgres",
+	})
+}
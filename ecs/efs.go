@@ -0,0 +1,281 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	efsapi "github.com/aws/aws-sdk-go/service/efs"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/sirupsen/logrus"
+)
+
+// EFS API constraints, see https://docs.aws.amazon.com/efs/latest/ug/API_CreateFileSystem.html and
+// https://docs.aws.amazon.com/efs/latest/ug/API_CreateAccessPoint.html
+const (
+	throughputModeElastic  = "elastic"
+	maxRootDirectoryLength = 100
+	defaultRootPermissions = "0755"
+)
+
+var (
+	efsPerformanceModes       = []string{efsapi.PerformanceModeGeneralPurpose, efsapi.PerformanceModeMaxIo}
+	efsThroughputModes        = []string{efsapi.ThroughputModeBursting, efsapi.ThroughputModeProvisioned, throughputModeElastic}
+	efsTransitionsToIA        = append([]string{"AFTER_1_DAY"}, efsapi.TransitionToIARules_Values()...)
+	efsTransitionsToPrimary   = []string{"AFTER_1_ACCESS"}
+	efsBackupPolicyStatuses   = []string{efsapi.StatusEnabled, efsapi.StatusDisabled}
+	kmsKeyIDPattern           = regexp.MustCompile(`^([0-9a-f]{8}(-[0-9a-f]{4}){3}-[0-9a-f]{12}|mrk-[0-9a-f]{32}|alias/[\w/+=,.@-]+|arn:aws[\w-]*:kms:[\w-]+:\d{12}:(key|alias)/[\w/+=,.@-]+)$`)
+	posixPermissionsPattern   = regexp.MustCompile(`^[0-7]{3,4}$`)
+	accessPointOptionsKeys    = []string{"uid", "gid", "permissions", "root_directory"}
+	fileSystemDriverOptsKeys  = []string{"backup_policy", "kms_key_id", "lifecycle_policy", "performance_mode", "provisioned_throughput", "throughput_mode", "transition_to_primary_storage_class"}
+	supportedVolumeDriverOpts = append(append([]string{}, fileSystemDriverOptsKeys...), accessPointOptionsKeys...)
+)
+
+// fileSystemOptions are the EFS file system settings a volume can set by driver_opts
+type fileSystemOptions struct {
+	BackupPolicy                    string
+	KmsKeyID                        string
+	PerformanceMode                 string
+	ProvisionedThroughput           float64
+	ThroughputMode                  string
+	TransitionToIA                  string
+	TransitionToPrimaryStorageClass string
+}
+
+// accessPointOptions set the POSIX user applied to file system requests made through an access point, and the
+// directory exposed as file system root, created with owner and permissions when it doesn't exist
+type accessPointOptions struct {
+	UID           string
+	GID           string
+	Permissions   string
+	RootDirectory string
+}
+
+// volumeDriverOpts normalizes volume driver_opts keys, so that both `throughput_mode` and `throughput-mode` can be used
+func volumeDriverOpts(volume types.VolumeConfig) map[string]string {
+	opts := map[string]string{}
+	for key, value := range volume.DriverOpts {
+		opts[strings.ReplaceAll(key, "-", "_")] = strings.TrimSpace(value)
+	}
+	return opts
+}
+
+// getFileSystemOptions parses and validates EFS file system settings from volume driver_opts. Unsupported options,
+// which might be meant for another volume driver, are ignored.
+func getFileSystemOptions(name string, volume types.VolumeConfig) (fileSystemOptions, error) {
+	opts := volumeDriverOpts(volume)
+	for key := range opts {
+		if !contains(supportedVolumeDriverOpts, key) {
+			logrus.Warnf("volume %s: unsupported driver_opts %q is ignored", name, key)
+		}
+	}
+	options := fileSystemOptions{
+		BackupPolicy:                    strings.ToUpper(opts["backup_policy"]),
+		KmsKeyID:                        opts["kms_key_id"],
+		PerformanceMode:                 opts["performance_mode"],
+		ThroughputMode:                  opts["throughput_mode"],
+		TransitionToIA:                  strings.ToUpper(opts["lifecycle_policy"]),
+		TransitionToPrimaryStorageClass: strings.ToUpper(opts["transition_to_primary_storage_class"]),
+	}
+	if t, ok := opts["provisioned_throughput"]; ok {
+		throughput, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return options, fmt.Errorf("volume %s: provisioned_throughput must be a number of MiB/s", name)
+		}
+		options.ProvisionedThroughput = throughput
+	}
+	if err := options.validate(); err != nil {
+		return options, fmt.Errorf("volume %s: %w", name, err)
+	}
+	return options, nil
+}
+
+func (o fileSystemOptions) validate() error {
+	enums := []struct {
+		option string
+		value  string
+		values []string
+	}{
+		{"backup_policy", o.BackupPolicy, efsBackupPolicyStatuses},
+		{"performance_mode", o.PerformanceMode, efsPerformanceModes},
+		{"throughput_mode", o.ThroughputMode, efsThroughputModes},
+		{"lifecycle_policy", o.TransitionToIA, efsTransitionsToIA},
+		{"transition_to_primary_storage_class", o.TransitionToPrimaryStorageClass, efsTransitionsToPrimary},
+	}
+	for _, e := range enums {
+		if e.value != "" && !contains(e.values, e.value) {
+			return fmt.Errorf("%s must be one of %s", e.option, strings.Join(e.values, ", "))
+		}
+	}
+
+	switch {
+	case o.ThroughputMode == efsapi.ThroughputModeProvisioned && o.ProvisionedThroughput == 0:
+		return fmt.Errorf("throughput_mode %s requires provisioned_throughput to be set", efsapi.ThroughputModeProvisioned)
+	case o.ProvisionedThroughput != 0 && o.ThroughputMode != efsapi.ThroughputModeProvisioned:
+		return fmt.Errorf("provisioned_throughput requires throughput_mode to be %s", efsapi.ThroughputModeProvisioned)
+	case o.ProvisionedThroughput != 0 && o.ProvisionedThroughput < 1:
+		// upper limit depends on region and account quota, and is checked by EFS
+		return fmt.Errorf("provisioned_throughput must be at least 1 MiB/s")
+	case o.ThroughputMode == throughputModeElastic && o.PerformanceMode == efsapi.PerformanceModeMaxIo:
+		return fmt.Errorf("throughput_mode %s can't be used with performance_mode %s", throughputModeElastic, efsapi.PerformanceModeMaxIo)
+	case o.KmsKeyID != "" && !kmsKeyIDPattern.MatchString(o.KmsKeyID):
+		return fmt.Errorf("kms_key_id must be a KMS key ID, key ARN, alias or alias ARN")
+	}
+	return nil
+}
+
+// transitionToPrimaryStorageClass adds the lifecycle policy to move files back from infrequent access storage, which
+// is not supported by goformation. Each policy sets a single transition, so it comes after the existing ones
+func transitionToPrimaryStorageClass(options fileSystemOptions, existing int) map[string]interface{} {
+	if options.TransitionToPrimaryStorageClass == "" {
+		return nil
+	}
+	policies := make([]interface{}, existing+1)
+	for i := range policies {
+		policies[i] = map[string]interface{}{}
+	}
+	policies[existing] = map[string]interface{}{
+		"TransitionToPrimaryStorageClass": options.TransitionToPrimaryStorageClass,
+	}
+	return map[string]interface{}{
+		extraProperties: map[string]interface{}{
+			"LifecyclePolicies": policies,
+		},
+	}
+}
+
+// getVolumeAccessPointOptions parses and validates access point settings from volume driver_opts
+func getVolumeAccessPointOptions(name string, volume types.VolumeConfig) (accessPointOptions, error) {
+	opts := volumeDriverOpts(volume)
+	options := accessPointOptions{
+		UID:           opts["uid"],
+		GID:           opts["gid"],
+		Permissions:   opts["permissions"],
+		RootDirectory: opts["root_directory"],
+	}
+	if err := options.validate(); err != nil {
+		return options, fmt.Errorf("volume %s: %w", name, err)
+	}
+	return options, nil
+}
+
+// getMountAccessPointOptions parses x-aws-access_point set on a service volume mount, to give service a dedicated
+// access point to the volume. It returns nil when mount uses the volume access point.
+func getMountAccessPointOptions(service types.ServiceConfig, mount types.ServiceVolumeConfig) (*accessPointOptions, error) {
+	x, ok := mount.Extensions[extensionAccessPoint]
+	if !ok {
+		return nil, nil
+	}
+	values, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("service %s: volume %s: %s must be a mapping", service.Name, mount.Source, extensionAccessPoint)
+	}
+	opts := map[string]string{}
+	for key, value := range values {
+		if !contains(accessPointOptionsKeys, key) {
+			return nil, fmt.Errorf("service %s: volume %s: unsupported %s option %q", service.Name, mount.Source, extensionAccessPoint, key)
+		}
+		// unquoted YAML octal numbers are parsed as decimal integers, like 0644 as 420
+		if _, ok := value.(string); key == "permissions" && !ok {
+			return nil, fmt.Errorf("service %s: volume %s: %s permissions must be a quoted octal string, like %q", service.Name, mount.Source, extensionAccessPoint, defaultRootPermissions)
+		}
+		opts[key] = fmt.Sprint(value)
+	}
+	options := accessPointOptions{
+		UID:           opts["uid"],
+		GID:           opts["gid"],
+		Permissions:   opts["permissions"],
+		RootDirectory: opts["root_directory"],
+	}
+	if err := options.validate(); err != nil {
+		return nil, fmt.Errorf("service %s: volume %s: invalid %s: %w", service.Name, mount.Source, extensionAccessPoint, err)
+	}
+	return &options, nil
+}
+
+func (o *accessPointOptions) validate() error {
+	for option, id := range map[string]string{"uid": o.UID, "gid": o.GID} {
+		if id == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+			return fmt.Errorf("%s must be a POSIX user or group ID", option)
+		}
+	}
+	if (o.UID == "") != (o.GID == "") {
+		return fmt.Errorf("uid and gid must be set together")
+	}
+	if o.Permissions != "" {
+		if !posixPermissionsPattern.MatchString(o.Permissions) {
+			return fmt.Errorf("permissions must be set in octal notation, like %s", defaultRootPermissions)
+		}
+		if o.RootDirectory == "" || o.UID == "" {
+			return fmt.Errorf("permissions require root_directory, uid and gid to be set")
+		}
+	}
+	if o.RootDirectory == "" {
+		return nil
+	}
+	if !path.IsAbs(o.RootDirectory) || len(o.RootDirectory) > maxRootDirectoryLength {
+		return fmt.Errorf("root_directory must be an absolute path of at most %d characters", maxRootDirectoryLength)
+	}
+	if o.UID != "" && o.Permissions == "" {
+		o.Permissions = defaultRootPermissions
+	}
+	return nil
+}
+
+// accessPointResourceName returns the access point service uses to mount volume, either dedicated to service when
+// set by x-aws-access_point, or shared by all services mounting the volume
+func accessPointResourceName(service types.ServiceConfig, volume string) string {
+	for _, mount := range service.Volumes {
+		if _, ok := mount.Extensions[extensionAccessPoint]; ok && mount.Source == volume {
+			return fmt.Sprintf("%s%sAccessPoint", normalizeResourceName(service.Name), normalizeResourceName(volume))
+		}
+	}
+	return fmt.Sprintf("%sAccessPoint", normalizeResourceName(volume))
+}
+
+// serviceAccessPoints returns the dedicated access points service requires, indexed by volume name
+func serviceAccessPoints(service types.ServiceConfig) (map[string]accessPointOptions, error) {
+	accessPoints := map[string]accessPointOptions{}
+	mounts := map[string]bool{}
+	for _, mount := range service.Volumes {
+		if mount.Type != types.VolumeTypeVolume {
+			continue
+		}
+		options, err := getMountAccessPointOptions(service, mount)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := mounts[mount.Source]; ok {
+			if existing, dedicated := accessPoints[mount.Source]; dedicated != (options != nil) || (options != nil && existing != *options) {
+				return nil, fmt.Errorf("service %s: volume %s is mounted with distinct %s", service.Name, mount.Source, extensionAccessPoint)
+			}
+			continue
+		}
+		mounts[mount.Source] = true
+		if options != nil {
+			accessPoints[mount.Source] = *options
+		}
+	}
+	return accessPoints, nil
+}
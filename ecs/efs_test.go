@@ -0,0 +1,237 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/efs"
+	"github.com/awslabs/goformation/v4/cloudformation/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/sanathkr/go-yaml"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func noPreviousFileSystem(m *MockAPIMockRecorder) {
+	m.ListFileSystems(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+}
+
+func TestFileSystemOptions(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  test:
+    image: nginx
+volumes:
+  db-data:
+    driver_opts:
+      performance-mode: maxIO
+      throughput_mode: provisioned
+      provisioned_throughput: 128
+      lifecycle_policy: after_30_days
+      transition_to_primary_storage_class: AFTER_1_ACCESS
+      backup_policy: enabled
+      kms_key_id: alias/efs
+`, nil, useDefaultVPC, noPreviousFileSystem)
+	fs := template.Resources["DbdataFilesystem"].(*efs.FileSystem)
+	assert.Equal(t, fs.PerformanceMode, "maxIO")
+	assert.Equal(t, fs.ThroughputMode, "provisioned")
+	assert.Equal(t, fs.ProvisionedThroughputInMibps, float64(128))
+	assert.Equal(t, fs.BackupPolicy.Status, "ENABLED")
+	assert.Equal(t, fs.KmsKeyId, "alias/efs")
+
+	bytes, err := marshall(template, "yaml")
+	assert.NilError(t, err)
+	var marshalled struct {
+		Resources map[string]struct {
+			Properties struct {
+				LifecyclePolicies []map[string]string `yaml:"LifecyclePolicies"`
+			} `yaml:"Properties"`
+		} `yaml:"Resources"`
+	}
+	assert.NilError(t, yaml.Unmarshal(bytes, &marshalled))
+	assert.DeepEqual(t, marshalled.Resources["DbdataFilesystem"].Properties.LifecyclePolicies, []map[string]string{
+		{"TransitionToIA": "AFTER_30_DAYS"},
+		{"TransitionToPrimaryStorageClass": "AFTER_1_ACCESS"},
+	})
+}
+
+func TestFileSystemOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		driverOpts string
+		err        string
+	}{
+		{
+			name:       "performance mode",
+			driverOpts: "{performance_mode: fast}",
+			err:        "volume db-data: performance_mode must be one of generalPurpose, maxIO",
+		},
+		{
+			name:       "missing provisioned throughput",
+			driverOpts: "{throughput_mode: provisioned}",
+			err:        "volume db-data: throughput_mode provisioned requires provisioned_throughput to be set",
+		},
+		{
+			name:       "provisioned throughput range",
+			driverOpts: "{throughput_mode: provisioned, provisioned_throughput: 0.5}",
+			err:        "volume db-data: provisioned_throughput must be at least 1 MiB/s",
+		},
+		{
+			name:       "elastic max io",
+			driverOpts: "{throughput_mode: elastic, performance_mode: maxIO}",
+			err:        "volume db-data: throughput_mode elastic can't be used with performance_mode maxIO",
+		},
+		{
+			name:       "lifecycle policy",
+			driverOpts: "{lifecycle_policy: AFTER_2_DAYS}",
+			err:        "volume db-data: lifecycle_policy must be one of AFTER_1_DAY, AFTER_7_DAYS, AFTER_14_DAYS, AFTER_30_DAYS, AFTER_60_DAYS, AFTER_90_DAYS",
+		},
+		{
+			name:       "kms key",
+			driverOpts: "{kms_key_id: mykey}",
+			err:        "volume db-data: kms_key_id must be a KMS key ID, key ARN, alias or alias ARN",
+		},
+		{
+			name:       "uid without gid",
+			driverOpts: "{uid: 1000}",
+			err:        "volume db-data: uid and gid must be set together",
+		},
+		{
+			name:       "relative root directory",
+			driverOpts: "{root_directory: data}",
+			err:        "volume db-data: root_directory must be an absolute path of at most 100 characters",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convertYaml(t, `
+services:
+  test:
+    image: nginx
+volumes:
+  db-data:
+    driver_opts: `+tt.driverOpts+`
+`, errors.New(tt.err), useDefaultVPC, noPreviousFileSystem)
+		})
+	}
+}
+
+func TestServiceAccessPoint(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  db:
+    image: postgres
+    volumes:
+      - type: volume
+        source: data
+        target: /var/lib/postgresql/data
+        x-aws-access_point:
+          uid: 999
+          gid: 999
+          root_directory: /postgres
+  backup:
+    image: backup
+    volumes:
+      - data:/backup:ro
+volumes:
+  data: {}
+`, nil, useDefaultVPC, noPreviousFileSystem)
+	ap := template.Resources["DbDataAccessPoint"].(*efs.AccessPoint)
+	assert.Equal(t, ap.PosixUser.Uid, "999")
+	assert.Equal(t, ap.RootDirectory.Path, "/postgres")
+	assert.Equal(t, ap.RootDirectory.CreationInfo.Permissions, "0755")
+	assert.Check(t, template.Resources["DataAccessPoint"] != nil)
+
+	db := template.Resources["DbTaskDefinition"].(*ecs.TaskDefinition)
+	assert.Equal(t, db.Volumes[0].EFSVolumeConfiguration.AuthorizationConfig.(*ecs.TaskDefinition_AuthorizationConfig).AccessPointId, cloudformation.Ref("DbDataAccessPoint"))
+	backup := template.Resources["BackupTaskDefinition"].(*ecs.TaskDefinition)
+	assert.Equal(t, backup.Volumes[0].EFSVolumeConfiguration.AuthorizationConfig.(*ecs.TaskDefinition_AuthorizationConfig).AccessPointId, cloudformation.Ref("DataAccessPoint"))
+
+	role := template.Resources["DbTaskRole"].(*iam.Role)
+	policy := role.Policies[0].PolicyDocument.(PolicyDocument)
+	assert.Equal(t, policy.Statement[0].Condition.StringEquals["elasticfilesystem:AccessPointArn"], cloudformation.Ref("DbDataAccessPoint"))
+}
+
+func TestServiceAccessPointValidation(t *testing.T) {
+	convertYaml(t, `
+services:
+  db:
+    image: postgres
+    volumes:
+      - type: volume
+        source: data
+        target: /data
+        x-aws-access_point:
+          uid: 999
+          gid: 999
+          permissions: "777"
+volumes:
+  data: {}
+`, errors.New("service db: volume data: invalid x-aws-access_point: permissions require root_directory, uid and gid to be set"),
+		useDefaultVPC, noPreviousFileSystem)
+}
+
+func TestServiceAccessPointUnquotedPermissions(t *testing.T) {
+	convertYaml(t, `
+services:
+  db:
+    image: postgres
+    volumes:
+      - type: volume
+        source: data
+        target: /data
+        x-aws-access_point:
+          uid: 999
+          gid: 999
+          root_directory: /db
+          permissions: 0644
+volumes:
+  data: {}
+`, errors.New(`service db: volume data: x-aws-access_point permissions must be a quoted octal string, like "0755"`),
+		useDefaultVPC, noPreviousFileSystem)
+}
+
+func TestFileSystemUnsupportedOption(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+	template := convertYaml(t, `
+services:
+  test:
+    image: nginx
+volumes:
+  db-data:
+    driver_opts:
+      encrypted: "false"
+      throughput-mode: provisioned
+      provisioned_throughput: 2048
+`, nil, useDefaultVPC, noPreviousFileSystem)
+	f := template.Resources["DbdataFilesystem"].(*efs.FileSystem)
+	assert.Equal(t, f.ProvisionedThroughputInMibps, float64(2048)) //nolint:staticcheck
+	var warnings []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			warnings = append(warnings, entry.Message)
+		}
+	}
+	assert.Check(t, is.Contains(warnings, `volume db-data: unsupported driver_opts "encrypted" is ignored`))
+}
@@ -0,0 +1,264 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+// eventsPollInterval is the delay between checks for new events while streaming project events
+const eventsPollInterval = 5 * time.Second
+
+// serviceEventStatuses classifies ECS service events, which only come with a human readable message
+var serviceEventStatuses = []struct {
+	pattern string
+	status  string
+}{
+	{"has reached a steady state", "SteadyState"},
+	{"deployment completed", "DeploymentCompleted"},
+	{"deployment failed", "DeploymentFailed"},
+	{"was unable to place a task", "PlacementFailed"},
+	{"has started", "TasksStarted"},
+	{"has stopped", "TasksStopped"},
+	{"deregistered", "TargetsDeregistered"},
+	{"registered", "TargetsRegistered"},
+}
+
+// eventsState tracks events already reported, so that polling only emits new ones
+type eventsState struct {
+	since     time.Time
+	known     map[string]struct{}
+	tasks     map[string]string
+	lastStack string
+}
+
+func (b *ecsAPIService) Events(ctx context.Context, projectName string, options api.EventsOptions) error {
+	stackID, err := b.aws.GetStackID(ctx, projectName)
+	if err != nil {
+		return err
+	}
+	state := &eventsState{
+		since: time.Now(),
+		known: map[string]struct{}{},
+		tasks: map[string]string{},
+	}
+	for {
+		events, err := b.pollEvents(ctx, projectName, stackID, state)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if len(options.Services) > 0 && !contains(options.Services, event.Service) {
+				continue
+			}
+			if err := options.Consumer(event); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(eventsPollInterval):
+		}
+	}
+}
+
+// pollEvents collects stack events, ECS service events and task state changes which occurred since last poll
+func (b *ecsAPIService) pollEvents(ctx context.Context, projectName string, stackID string, state *eventsState) ([]api.Event, error) {
+	resources, err := b.aws.ListStackResources(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	cluster, svcArns, logicalIDs := resources.ecsServices()
+	services := map[string]serviceEvents{}
+	if len(svcArns) > 0 {
+		if cluster == "" {
+			cluster, err = b.aws.GetStackClusterID(ctx, stackID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		services, err = b.aws.DescribeServiceEvents(ctx, cluster, svcArns)
+		if err != nil {
+			return nil, err
+		}
+	}
+	names := map[string]string{}
+	for arn, service := range services {
+		names[logicalIDs[arn]] = service.Service
+	}
+
+	stackEvents, err := b.aws.DescribeStackEventsSince(ctx, stackID, state.lastStack)
+	if err != nil {
+		return nil, err
+	}
+	if len(stackEvents) > 0 {
+		state.lastStack = aws.StringValue(stackEvents[0].EventId)
+	}
+	events := state.stackEvents(stackEvents, names)
+
+	for arn, service := range services {
+		events = append(events, state.serviceEvents(service)...)
+		for _, stopped := range []bool{false, true} {
+			tasks, err := b.aws.GetServiceTasks(ctx, cluster, arn, stopped)
+			if err != nil {
+				return nil, err
+			}
+			taskEvents, err := state.taskEvents(service.Service, tasks)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, taskEvents...)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// isNew checks an event has not been reported yet, and occurred after events streaming started
+func (s *eventsState) isNew(id string, timestamp time.Time) bool {
+	if _, ok := s.known[id]; ok {
+		return false
+	}
+	s.known[id] = struct{}{}
+	return !timestamp.Before(s.since)
+}
+
+// stackEvents converts CloudFormation stack events. Events about an ECS service resource are attributed to the
+// compose service, indexed by logical ID in names
+func (s *eventsState) stackEvents(stackEvents []*cloudformation.StackEvent, names map[string]string) []api.Event {
+	var events []api.Event
+	for _, event := range stackEvents {
+		timestamp := aws.TimeValue(event.Timestamp)
+		if !s.isNew(aws.StringValue(event.EventId), timestamp) {
+			continue
+		}
+		resource := aws.StringValue(event.LogicalResourceId)
+		attributes := map[string]string{
+			"resource": resource,
+			"type":     aws.StringValue(event.ResourceType),
+		}
+		if reason := aws.StringValue(event.ResourceStatusReason); reason != "" {
+			attributes["reason"] = reason
+		}
+		events = append(events, api.Event{
+			Timestamp:  timestamp,
+			Service:    names[resource],
+			Status:     toCamelCase(aws.StringValue(event.ResourceStatus)),
+			Attributes: attributes,
+		})
+	}
+	return events
+}
+
+func (s *eventsState) serviceEvents(service serviceEvents) []api.Event {
+	var events []api.Event
+	for _, event := range service.Events {
+		timestamp := aws.TimeValue(event.CreatedAt)
+		if !s.isNew(aws.StringValue(event.Id), timestamp) {
+			continue
+		}
+		message := aws.StringValue(event.Message)
+		events = append(events, api.Event{
+			Timestamp: timestamp,
+			Service:   service.Service,
+			Status:    serviceEventStatus(message),
+			Attributes: map[string]string{
+				"message": message,
+			},
+		})
+	}
+	return events
+}
+
+func serviceEventStatus(message string) string {
+	for _, s := range serviceEventStatuses {
+		if strings.Contains(message, s.pattern) {
+			return s.status
+		}
+	}
+	return "ServiceEvent"
+}
+
+// taskEvents reports tasks which changed status since last poll
+func (s *eventsState) taskEvents(service string, tasks []*ecs.Task) ([]api.Event, error) {
+	var events []api.Event
+	for _, task := range tasks {
+		taskArn := aws.StringValue(task.TaskArn)
+		status := aws.StringValue(task.LastStatus)
+		if s.tasks[taskArn] == status {
+			continue
+		}
+		s.tasks[taskArn] = status
+
+		timestamp := taskStatusTime(task)
+		if timestamp.Before(s.since) {
+			continue
+		}
+		id, err := arn.Parse(taskArn)
+		if err != nil {
+			return nil, err
+		}
+		attributes := map[string]string{
+			"taskDefinition": aws.StringValue(task.TaskDefinitionArn),
+		}
+		if task.StoppedAt != nil {
+			attributes["stopCode"] = aws.StringValue(task.StopCode)
+			attributes["reason"] = aws.StringValue(task.StoppedReason)
+			for _, container := range task.Containers {
+				if container.ExitCode != nil {
+					attributes[fmt.Sprintf("exitCode.%s", aws.StringValue(container.Name))] = fmt.Sprint(aws.Int64Value(container.ExitCode))
+				}
+			}
+		}
+		events = append(events, api.Event{
+			Timestamp:  timestamp,
+			Service:    service,
+			Container:  id.Resource,
+			Status:     toCamelCase(status),
+			Attributes: attributes,
+		})
+	}
+	return events, nil
+}
+
+// taskStatusTime returns the time a task reached its last known status
+func taskStatusTime(task *ecs.Task) time.Time {
+	switch {
+	case task.StoppedAt != nil:
+		return aws.TimeValue(task.StoppedAt)
+	case task.StartedAt != nil:
+		return aws.TimeValue(task.StartedAt)
+	case task.CreatedAt != nil:
+		return aws.TimeValue(task.CreatedAt)
+	default:
+		return time.Now()
+	}
+}
@@ -0,0 +1,148 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestPollEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+
+	since := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	before := since.Add(-time.Minute)
+	cluster := "arn:aws:ecs:us-east-1:012345678910:cluster/test"
+	service := "arn:aws:ecs:us-east-1:012345678910:service/test/test-FrontService-1234"
+	task := "arn:aws:ecs:us-east-1:012345678910:task/test/0123456789"
+
+	m.EXPECT().ListStackResources(gomock.Any(), "test").Return(stackResources{
+		{LogicalID: "Cluster", Type: "AWS::ECS::Cluster", ARN: cluster},
+		{LogicalID: "FrontService", Type: "AWS::ECS::Service", ARN: service},
+	}, nil).Times(2)
+	m.EXPECT().DescribeServiceEvents(gomock.Any(), cluster, []string{service}).Return(map[string]serviceEvents{
+		service: {
+			Service: "front",
+			Events: []*ecs.ServiceEvent{
+				{Id: aws.String("e1"), CreatedAt: aws.Time(before), Message: aws.String("(service front) has reached a steady state.")},
+				{Id: aws.String("e2"), CreatedAt: aws.Time(since.Add(2 * time.Second)), Message: aws.String("(service front) was unable to place a task.")},
+			},
+		},
+	}, nil).Times(2)
+	m.EXPECT().DescribeStackEventsSince(gomock.Any(), "stackID", "").Return([]*cloudformation.StackEvent{
+		{EventId: aws.String("s2"), Timestamp: aws.Time(since.Add(time.Second)), LogicalResourceId: aws.String("FrontService"),
+			ResourceType: aws.String("AWS::ECS::Service"), ResourceStatus: aws.String("UPDATE_IN_PROGRESS")},
+		{EventId: aws.String("s1"), Timestamp: aws.Time(before), LogicalResourceId: aws.String("LoadBalancer"), ResourceStatus: aws.String("CREATE_COMPLETE")},
+	}, nil)
+	m.EXPECT().DescribeStackEventsSince(gomock.Any(), "stackID", "s2").Return(nil, nil)
+	m.EXPECT().GetServiceTasks(gomock.Any(), cluster, service, false).Return(nil, nil).Times(2)
+	m.EXPECT().GetServiceTasks(gomock.Any(), cluster, service, true).Return([]*ecs.Task{
+		{
+			TaskArn:           aws.String(task),
+			TaskDefinitionArn: aws.String("front:2"),
+			LastStatus:        aws.String("STOPPED"),
+			StoppedAt:         aws.Time(since.Add(3 * time.Second)),
+			StopCode:          aws.String("EssentialContainerExited"),
+			StoppedReason:     aws.String("Essential container in task exited"),
+			Containers: []*ecs.Container{
+				{Name: aws.String("front"), ExitCode: aws.Int64(1)},
+			},
+		},
+	}, nil).Times(2)
+
+	backend := &ecsAPIService{aws: m}
+	state := &eventsState{
+		since: since,
+		known: map[string]struct{}{},
+		tasks: map[string]string{},
+	}
+	events, err := backend.pollEvents(context.TODO(), "test", "stackID", state)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []api.Event{
+		{
+			Timestamp: since.Add(time.Second),
+			Service:   "front",
+			Status:    "UpdateInProgress",
+			Attributes: map[string]string{
+				"resource": "FrontService",
+				"type":     "AWS::ECS::Service",
+			},
+		},
+		{
+			Timestamp: since.Add(2 * time.Second),
+			Service:   "front",
+			Status:    "PlacementFailed",
+			Attributes: map[string]string{
+				"message": "(service front) was unable to place a task.",
+			},
+		},
+		{
+			Timestamp: since.Add(3 * time.Second),
+			Service:   "front",
+			Container: "task/test/0123456789",
+			Status:    "Stopped",
+			Attributes: map[string]string{
+				"taskDefinition": "front:2",
+				"stopCode":       "EssentialContainerExited",
+				"reason":         "Essential container in task exited",
+				"exitCode.front": "1",
+			},
+		},
+	})
+
+	events, err = backend.pollEvents(context.TODO(), "test", "stackID", state)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+}
+
+func TestPollEventsExternalCluster(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+
+	service := "arn:aws:ecs:us-east-1:012345678910:service/shared/test-FrontService-1234"
+	m.EXPECT().ListStackResources(gomock.Any(), "test").Return(stackResources{
+		{LogicalID: "FrontService", Type: "AWS::ECS::Service", ARN: service},
+	}, nil)
+	m.EXPECT().GetStackClusterID(gomock.Any(), "stackID").Return("shared", nil)
+	m.EXPECT().DescribeServiceEvents(gomock.Any(), "shared", []string{service}).Return(map[string]serviceEvents{
+		service: {Service: "front"},
+	}, nil)
+	m.EXPECT().DescribeStackEventsSince(gomock.Any(), "stackID", "").Return(nil, nil)
+	m.EXPECT().GetServiceTasks(gomock.Any(), "shared", service, false).Return(nil, nil)
+	m.EXPECT().GetServiceTasks(gomock.Any(), "shared", service, true).Return(nil, nil)
+
+	backend := &ecsAPIService{aws: m}
+	state := &eventsState{
+		since: time.Now(),
+		known: map[string]struct{}{},
+		tasks: map[string]string{},
+	}
+	events, err := backend.pollEvents(context.TODO(), "test", "stackID", state)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+}
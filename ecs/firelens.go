@@ -0,0 +1,129 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"fmt"
+
+	ecsapi "github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/compose-spec/compose-go/types"
+)
+
+const (
+	logDriverFluentbit = "fluentbit"
+	logRouterImage     = "public.ecr.aws/aws-observability/aws-for-fluent-bit:stable"
+
+	// logging options used to configure the log router, which are not passed to the output plugin
+	logOptionRouterImage     = "firelens-image"
+	logOptionConfigFileType  = "config-file-type"
+	logOptionConfigFileValue = "config-file-value"
+
+	// logOptionOutputPlugin selects the Fluent Bit output plugin
+	logOptionOutputPlugin = "Name"
+)
+
+// outputPluginActions are the IAM actions Fluent Bit output plugins for AWS services require from the task role
+var outputPluginActions = map[string][]string{
+	"cloudwatch":       {"logs:CreateLogGroup", "logs:CreateLogStream", "logs:DescribeLogStreams", "logs:PutLogEvents"},
+	"cloudwatch_logs":  {"logs:CreateLogGroup", "logs:CreateLogStream", "logs:DescribeLogStreams", "logs:PutLogEvents"},
+	"firehose":         {"firehose:PutRecordBatch"},
+	"kinesis_firehose": {"firehose:PutRecordBatch"},
+	"kinesis_streams":  {"kinesis:PutRecords"},
+	"s3":               {"s3:PutObject"},
+}
+
+func useFirelens(service types.ServiceConfig) bool {
+	if service.Logging == nil {
+		return false
+	}
+	switch service.Logging.Driver {
+	case ecsapi.LogDriverAwsfirelens, logDriverFluentbit:
+		return true
+	default:
+		return false
+	}
+}
+
+// createLogRouter declares a Fluent Bit FireLens log router sidecar container, and the log configuration for service
+// container to route logs through it. The log router own logs are sent to CloudWatch using routerLogConfiguration
+func createLogRouter(service types.ServiceConfig, routerLogConfiguration *ecs.TaskDefinition_LogConfiguration) (ecs.TaskDefinition_ContainerDefinition, *ecs.TaskDefinition_LogConfiguration, error) {
+	switch fileType := service.Logging.Options[logOptionConfigFileType]; fileType {
+	case "", "file":
+	case "s3":
+		// https://docs.aws.amazon.com/AmazonECS/latest/developerguide/firelens-taskdef.html#firelens-taskdef-customconfig
+		if !requireEC2(service) {
+			return ecs.TaskDefinition_ContainerDefinition{}, nil, fmt.Errorf("service %s: logging option %s %q is not supported on Fargate, use \"file\"", service.Name, logOptionConfigFileType, fileType)
+		}
+	default:
+		return ecs.TaskDefinition_ContainerDefinition{}, nil, fmt.Errorf("service %s: logging option %s must be either \"file\" or \"s3\"", service.Name, logOptionConfigFileType)
+	}
+
+	image := logRouterImage
+	firelensOptions := map[string]string{
+		"enable-ecs-log-metadata": "true",
+	}
+	outputOptions := map[string]string{}
+	for k, v := range service.Logging.Options {
+		switch k {
+		case logOptionRouterImage:
+			image = v
+		case logOptionConfigFileType, logOptionConfigFileValue:
+			firelensOptions[k] = v
+		default:
+			outputOptions[k] = v
+		}
+	}
+
+	router := ecs.TaskDefinition_ContainerDefinition{
+		Name:      fmt.Sprintf("%s_LogRouter", normalizeResourceName(service.Name)),
+		Image:     image,
+		Essential: true,
+		FirelensConfiguration: &ecs.TaskDefinition_FirelensConfiguration{
+			Type:    logDriverFluentbit,
+			Options: firelensOptions,
+		},
+		LogConfiguration:  routerLogConfiguration,
+		MemoryReservation: 50,
+	}
+	return router, &ecs.TaskDefinition_LogConfiguration{
+		LogDriver: ecsapi.LogDriverAwsfirelens,
+		Options:   outputOptions,
+	}, nil
+}
+
+// logRouterPolicyDocument grants the task role permissions the Fluent Bit output plugin requires to send logs to an
+// AWS service. Target resources are set by plugin options, so can't be restricted here
+func logRouterPolicyDocument(service types.ServiceConfig) *PolicyDocument {
+	if !useFirelens(service) {
+		return nil
+	}
+	actions, ok := outputPluginActions[service.Logging.Options[logOptionOutputPlugin]]
+	if !ok {
+		return nil
+	}
+	return &PolicyDocument{
+		Version: "2012-10-17", // https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_elements_version.html
+		Statement: []PolicyStatement{
+			{
+				Effect:   "Allow",
+				Action:   actions,
+				Resource: []string{"*"},
+			},
+		},
+	}
+}
@@ -18,7 +18,6 @@ package ecs
 
 import (
 	"fmt"
-	"math"
 	"strconv"
 
 	"github.com/compose-spec/compose-go/types"
@@ -79,6 +78,49 @@ var gpufamily = family{
 	},
 }
 
+var generalPurposeFamily = family{
+	{
+		id:     "m5.large",
+		cpus:   2,
+		memory: 8 * units.GiB,
+	},
+	{
+		id:     "m5.xlarge",
+		cpus:   4,
+		memory: 16 * units.GiB,
+	},
+	{
+		id:     "m5.2xlarge",
+		cpus:   8,
+		memory: 32 * units.GiB,
+	},
+	{
+		id:     "m5.4xlarge",
+		cpus:   16,
+		memory: 64 * units.GiB,
+	},
+	{
+		id:     "m5.8xlarge",
+		cpus:   32,
+		memory: 128 * units.GiB,
+	},
+	{
+		id:     "m5.12xlarge",
+		cpus:   48,
+		memory: 192 * units.GiB,
+	},
+	{
+		id:     "m5.16xlarge",
+		cpus:   64,
+		memory: 256 * units.GiB,
+	},
+	{
+		id:     "m5.24xlarge",
+		cpus:   96,
+		memory: 384 * units.GiB,
+	},
+}
+
 type filterFn func(machine) bool
 
 func (f family) filter(fn filterFn) family {
@@ -98,15 +140,23 @@ func (f family) firstOrError(msg string, args ...interface{}) (machine, error) {
 	return f[0], nil
 }
 
-func guessMachineType(project *types.Project) (string, error) {
-	// we select a machine type to match all gpus-bound services requirements
-	// once https://github.com/aws/containers-roadmap/issues/631 is implemented we can define dedicated CapacityProviders per service.
-	requirements, err := getResourceRequirements(project)
+// guessMachineType selects the smallest machine type to match service requirements, from Amazon EC2 G4 instance
+// types for services requiring GPUs or general purpose M5 instance types otherwise
+func guessMachineType(service types.ServiceConfig) (string, error) {
+	requirements, err := toResourceRequirements(service)
 	if err != nil {
 		return "", err
 	}
+	if requirements == nil {
+		requirements = &resourceRequirements{}
+	}
+
+	candidates, name := generalPurposeFamily, "M5"
+	if requirements.gpus > 0 {
+		candidates, name = gpufamily, "G4"
+	}
 
-	instanceType, err := gpufamily.
+	instanceType, err := candidates.
 		filter(func(m machine) bool {
 			return m.memory > requirements.memory // actual memory available for ECS tasks < total machine memory
 		}).
@@ -116,7 +166,7 @@ func guessMachineType(project *types.Project) (string, error) {
 		filter(func(m machine) bool {
 			return m.gpus >= requirements.gpus
 		}).
-		firstOrError("none of the Amazon EC2 G4 instance types meet the requirements for memory:%d cpu:%f gpus:%d", requirements.memory, requirements.cpus, requirements.gpus)
+		firstOrError("none of the Amazon EC2 %s instance types meet the requirements for memory:%d cpu:%f gpus:%d", name, requirements.memory, requirements.cpus, requirements.gpus)
 	if err != nil {
 		return "", err
 	}
@@ -129,44 +179,6 @@ type resourceRequirements struct {
 	gpus   int64
 }
 
-func getResourceRequirements(project *types.Project) (*resourceRequirements, error) {
-	return toResourceRequirementsSlice(project).
-		filter(func(requirements *resourceRequirements) bool {
-			return requirements != nil && requirements.gpus != 0
-		}).
-		max()
-}
-
-type eitherRequirementsOrError struct {
-	requirements []*resourceRequirements
-	err          error
-}
-
-func toResourceRequirementsSlice(project *types.Project) eitherRequirementsOrError {
-	var requirements []*resourceRequirements
-	for _, service := range project.Services {
-		r, err := toResourceRequirements(service)
-		if err != nil {
-			return eitherRequirementsOrError{nil, err}
-		}
-		requirements = append(requirements, r)
-	}
-	return eitherRequirementsOrError{requirements, nil}
-}
-
-func (r eitherRequirementsOrError) filter(fn func(*resourceRequirements) bool) eitherRequirementsOrError {
-	if r.err != nil {
-		return r
-	}
-	var requirements []*resourceRequirements
-	for _, req := range r.requirements {
-		if fn(req) {
-			requirements = append(requirements, req)
-		}
-	}
-	return eitherRequirementsOrError{requirements, nil}
-}
-
 func toResourceRequirements(service types.ServiceConfig) (*resourceRequirements, error) {
 	if service.Deploy == nil {
 		return nil, nil
@@ -215,39 +227,3 @@ func toResourceRequirements(service types.ServiceConfig) (*resourceRequirements,
 		gpus:   requiredGPUs,
 	}, nil
 }
-
-func (r resourceRequirements) combine(o *resourceRequirements) resourceRequirements {
-	if o == nil {
-		return r
-	}
-	return resourceRequirements{
-		memory: maxUnitBytes(r.memory, o.memory),
-		cpus:   math.Max(r.cpus, o.cpus),
-		gpus:   maxInt64(r.gpus, o.gpus),
-	}
-}
-
-func (r eitherRequirementsOrError) max() (*resourceRequirements, error) {
-	if r.err != nil {
-		return nil, r.err
-	}
-	min := resourceRequirements{}
-	for _, req := range r.requirements {
-		min = min.combine(req)
-	}
-	return &min, nil
-}
-
-func maxInt64(a, b int64) int64 {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-func maxUnitBytes(a, b types.UnitBytes) types.UnitBytes {
-	if a > b {
-		return a
-	}
-	return b
-}
@@ -102,7 +102,7 @@ services:
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			project := loadConfig(t, tt.yaml)
-			got, err := guessMachineType(project)
+			got, err := guessMachineType(project.Services[0])
 			if (err != nil) != tt.wantErr {
 				t.Errorf("guessMachineType() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,195 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/awslabs/goformation/v4/cloudformation/elasticloadbalancingv2"
+	cloudmap "github.com/awslabs/goformation/v4/cloudformation/servicediscovery"
+	"github.com/compose-spec/compose-go/types"
+)
+
+// Target group health check constraints, see https://docs.aws.amazon.com/elasticloadbalancing/latest/APIReference/API_CreateTargetGroup.html
+const (
+	minHealthCheckInterval  = 5
+	maxHealthCheckInterval  = 300
+	minHealthCheckTimeout   = 2
+	maxHealthCheckTimeout   = 120
+	minHealthCheckThreshold = 2
+	maxHealthCheckThreshold = 10
+)
+
+// targetHealthCheck configures the load balancer target group health check, times are expressed in seconds
+type targetHealthCheck struct {
+	Path               string `json:"path,omitempty"`
+	Interval           int    `json:"interval,omitempty"`
+	Timeout            int    `json:"timeout,omitempty"`
+	HealthyThreshold   int    `json:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int    `json:"unhealthy_threshold,omitempty"`
+	Matcher            string `json:"matcher,omitempty"`
+}
+
+// defaultTargetHealthCheck returns sane defaults for protocol, HTTP services being considered healthy as long as they
+// don't report a server error. Network Load Balancer require healthy and unhealthy thresholds to be the same.
+func defaultTargetHealthCheck(protocol string) targetHealthCheck {
+	if protocol == elbv2.ProtocolEnumHttp || protocol == elbv2.ProtocolEnumHttps {
+		return targetHealthCheck{
+			Path:               "/",
+			Interval:           30,
+			Timeout:            5,
+			HealthyThreshold:   2,
+			UnhealthyThreshold: 3,
+			Matcher:            "200-399",
+		}
+	}
+	return targetHealthCheck{
+		Interval:           30,
+		HealthyThreshold:   3,
+		UnhealthyThreshold: 3,
+	}
+}
+
+// getTargetHealthCheck computes the target group health check for service, based on x-aws-healthcheck extension,
+// then compose healthcheck timings, then defaults for protocol
+func getTargetHealthCheck(service types.ServiceConfig, protocol string) (targetHealthCheck, error) {
+	check := defaultTargetHealthCheck(protocol)
+	http := check.Path != ""
+	if hc := service.HealthCheck; hc != nil && !hc.Disable {
+		check = check.withComposeHealthCheck(hc, http)
+	}
+
+	if v, ok := service.Extensions[extensionHealthCheck]; ok {
+		marshalled, err := json.Marshal(v)
+		if err != nil {
+			return check, err
+		}
+		var x targetHealthCheck
+		err = json.Unmarshal(marshalled, &x)
+		if err != nil {
+			return check, fmt.Errorf("service %s has an invalid %s: %w", service.Name, extensionHealthCheck, err)
+		}
+		if !http && (x.Path != "" || x.Matcher != "" || x.Timeout != 0) {
+			return check, fmt.Errorf("service %s: %s path, matcher and timeout only apply to HTTP services", service.Name, extensionHealthCheck)
+		}
+		check = check.override(x)
+	}
+	return check, check.validate(service, http)
+}
+
+// withComposeHealthCheck adjusts timings to match the container healthcheck, within the range supported by load balancer
+func (c targetHealthCheck) withComposeHealthCheck(hc *types.HealthCheckConfig, http bool) targetHealthCheck {
+	if interval := durationToInt(hc.Interval); interval > 0 {
+		c.Interval = clamp(interval, minHealthCheckInterval, maxHealthCheckInterval)
+	}
+	if timeout := durationToInt(hc.Timeout); timeout > 0 && http {
+		c.Timeout = clamp(timeout, minHealthCheckTimeout, maxHealthCheckTimeout)
+	}
+	if c.Timeout >= c.Interval {
+		c.Timeout = c.Interval - 1
+	}
+	if hc.Retries != nil {
+		c.UnhealthyThreshold = clamp(int(*hc.Retries), minHealthCheckThreshold, maxHealthCheckThreshold)
+		if !http {
+			c.HealthyThreshold = c.UnhealthyThreshold
+		}
+	}
+	return c
+}
+
+func (c targetHealthCheck) override(x targetHealthCheck) targetHealthCheck {
+	if x.Path != "" {
+		c.Path = x.Path
+	}
+	if x.Interval != 0 {
+		c.Interval = x.Interval
+	}
+	if x.Timeout != 0 {
+		c.Timeout = x.Timeout
+	}
+	if x.HealthyThreshold != 0 {
+		c.HealthyThreshold = x.HealthyThreshold
+	}
+	if x.UnhealthyThreshold != 0 {
+		c.UnhealthyThreshold = x.UnhealthyThreshold
+	}
+	if x.Matcher != "" {
+		c.Matcher = x.Matcher
+	}
+	return c
+}
+
+func (c targetHealthCheck) validate(service types.ServiceConfig, http bool) error {
+	if http && !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("service %s: %s path must start with '/'", service.Name, extensionHealthCheck)
+	}
+	if c.Interval < minHealthCheckInterval || c.Interval > maxHealthCheckInterval {
+		return fmt.Errorf("service %s: %s interval must be between %d and %d seconds", service.Name, extensionHealthCheck, minHealthCheckInterval, maxHealthCheckInterval)
+	}
+	if http && (c.Timeout < minHealthCheckTimeout || c.Timeout > maxHealthCheckTimeout || c.Timeout >= c.Interval) {
+		return fmt.Errorf("service %s: %s timeout must be between %d and %d seconds, and lower than interval",
+			service.Name, extensionHealthCheck, minHealthCheckTimeout, maxHealthCheckTimeout)
+	}
+	for _, threshold := range []int{c.HealthyThreshold, c.UnhealthyThreshold} {
+		if threshold < minHealthCheckThreshold || threshold > maxHealthCheckThreshold {
+			return fmt.Errorf("service %s: %s thresholds must be between %d and %d", service.Name, extensionHealthCheck, minHealthCheckThreshold, maxHealthCheckThreshold)
+		}
+	}
+	if !http && c.HealthyThreshold != c.UnhealthyThreshold {
+		return fmt.Errorf("service %s: %s healthy and unhealthy thresholds must be equal for a Network Load Balancer", service.Name, extensionHealthCheck)
+	}
+	return nil
+}
+
+func (c targetHealthCheck) apply(group *elasticloadbalancingv2.TargetGroup) {
+	group.HealthCheckIntervalSeconds = c.Interval
+	group.HealthCheckTimeoutSeconds = c.Timeout
+	group.HealthyThresholdCount = c.HealthyThreshold
+	group.UnhealthyThresholdCount = c.UnhealthyThreshold
+	group.HealthCheckPath = c.Path
+	if c.Matcher != "" {
+		group.Matcher = &elasticloadbalancingv2.TargetGroup_Matcher{
+			HttpCode: c.Matcher,
+		}
+	}
+}
+
+// toServiceRegistryHealthCheck configures Cloud Map to rely on ECS reporting container health status, as Route 53
+// health checks can't be used with a private DNS namespace. Unhealthy tasks are removed from DNS once the container
+// healthcheck reports failure
+func toServiceRegistryHealthCheck(service types.ServiceConfig) *cloudmap.Service_HealthCheckCustomConfig {
+	threshold := 1
+	if hc := service.HealthCheck; hc != nil && !hc.Disable && hc.Retries != nil {
+		threshold = clamp(int(*hc.Retries), 1, maxHealthCheckThreshold)
+	}
+	return &cloudmap.Service_HealthCheckCustomConfig{
+		FailureThreshold: float64(threshold),
+	}
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation/elasticloadbalancingv2"
+	cloudmap "github.com/awslabs/goformation/v4/cloudformation/servicediscovery"
+	"gotest.tools/v3/assert"
+)
+
+func TestTargetGroupHealthCheckFromCompose(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  test:
+    image: nginx
+    ports:
+      - 80:80
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost"]
+      interval: 10s
+      timeout: 3s
+      retries: 5
+`, nil, useDefaultVPC)
+	group := template.Resources["TestTCP80TargetGroup"].(*elasticloadbalancingv2.TargetGroup)
+	assert.Equal(t, group.HealthCheckPath, "/")
+	assert.Equal(t, group.HealthCheckIntervalSeconds, 10)
+	assert.Equal(t, group.HealthCheckTimeoutSeconds, 3)
+	assert.Equal(t, group.UnhealthyThresholdCount, 5)
+	assert.Equal(t, group.Matcher.HttpCode, "200-399")
+
+	entry := template.Resources["TestServiceDiscoveryEntry"].(*cloudmap.Service)
+	assert.Equal(t, entry.HealthCheckCustomConfig.FailureThreshold, float64(5))
+}
+
+func TestTargetGroupHealthCheckExtension(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  test:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-healthcheck:
+      path: /health
+      interval: 15
+      healthy_threshold: 3
+      matcher: "200"
+`, nil, useDefaultVPC)
+	group := template.Resources["TestTCP80TargetGroup"].(*elasticloadbalancingv2.TargetGroup)
+	assert.Equal(t, group.HealthCheckPath, "/health")
+	assert.Equal(t, group.HealthCheckIntervalSeconds, 15)
+	assert.Equal(t, group.HealthCheckTimeoutSeconds, 5)
+	assert.Equal(t, group.HealthyThresholdCount, 3)
+	assert.Equal(t, group.Matcher.HttpCode, "200")
+}
+
+func TestTargetGroupHealthCheckNetwork(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  test:
+    image: redis
+    ports:
+      - 6379:6379
+    healthcheck:
+      test: ["CMD", "redis-cli", "ping"]
+      retries: 4
+`, nil, useDefaultVPC)
+	group := template.Resources["TestTCP6379TargetGroup"].(*elasticloadbalancingv2.TargetGroup)
+	assert.Equal(t, group.HealthCheckPath, "")
+	assert.Equal(t, group.HealthCheckTimeoutSeconds, 0)
+	assert.Check(t, group.Matcher == nil)
+	assert.Equal(t, group.HealthyThresholdCount, 4)
+	assert.Equal(t, group.UnhealthyThresholdCount, 4)
+}
+
+func TestInvalidTargetGroupHealthCheck(t *testing.T) {
+	convertYaml(t, `
+services:
+  test:
+    image: nginx
+    ports:
+      - 80:80
+    x-aws-healthcheck:
+      interval: 5
+      timeout: 10
+`, errors.New("service test: x-aws-healthcheck timeout must be between 2 and 120 seconds, and lower than interval"), useDefaultVPC)
+}
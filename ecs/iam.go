@@ -17,8 +17,6 @@
 package ecs
 
 import (
-	"fmt"
-
 	"github.com/awslabs/goformation/v4/cloudformation"
 )
 
@@ -26,6 +24,7 @@ const (
 	ecsTaskExecutionPolicy = "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy"
 	ecrReadOnlyPolicy      = "arn:aws:iam::aws:policy/AmazonEC2ContainerRegistryReadOnly"
 	ecsEC2InstanceRole     = "arn:aws:iam::aws:policy/service-role/AmazonEC2ContainerServiceforEC2Role"
+	codeDeployECSPolicy    = "arn:aws:iam::aws:policy/AWSCodeDeployRoleForECS"
 
 	actionGetSecretValue  = "secretsmanager:GetSecretValue"
 	actionGetParameters   = "ssm:GetParameters"
@@ -34,12 +33,16 @@ const (
 	actionGetMetrics      = "cloudwatch:GetMetricStatistics"
 	actionDescribeService = "ecs:DescribeServices"
 	actionUpdateService   = "ecs:UpdateService"
+	actionRunTask         = "ecs:RunTask"
+	actionPassRole        = "iam:PassRole"
 )
 
 var (
 	ecsTaskAssumeRolePolicyDocument     = policyDocument("ecs-tasks.amazonaws.com")
 	ec2InstanceAssumeRolePolicyDocument = policyDocument("ec2.amazonaws.com")
 	ausocalingAssumeRolePolicyDocument  = policyDocument("application-autoscaling.amazonaws.com")
+	eventsAssumeRolePolicyDocument      = policyDocument("events.amazonaws.com")
+	codeDeployAssumeRolePolicyDocument  = policyDocument("codedeploy.amazonaws.com")
 )
 
 func policyDocument(service string) PolicyDocument {
@@ -57,8 +60,7 @@ func policyDocument(service string) PolicyDocument {
 	}
 }
 
-func volumeMountPolicyDocument(volume string, filesystem string) PolicyDocument {
-	ap := fmt.Sprintf("%sAccessPoint", normalizeResourceName(volume))
+func volumeMountPolicyDocument(ap string, filesystem string) PolicyDocument {
 	return PolicyDocument{
 		Version: "2012-10-17", // https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_elements_version.html
 		Statement: []PolicyStatement{
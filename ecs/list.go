@@ -55,22 +55,7 @@ func (b *ecsAPIService) checkStackState(ctx context.Context, name string) error
 	if err != nil {
 		return err
 	}
-	svcArns := []string{}
-	svcNames := map[string]string{}
-	var cluster string
-	for _, r := range resources {
-		if r.Type == "AWS::ECS::Cluster" {
-			cluster = r.ARN
-			continue
-		}
-		if r.Type == "AWS::ECS::Service" {
-			if r.ARN == "" {
-				continue
-			}
-			svcArns = append(svcArns, r.ARN)
-			svcNames[r.ARN] = r.LogicalID
-		}
-	}
+	cluster, svcArns, svcNames := resources.ecsServices()
 	if len(svcArns) == 0 {
 		return nil
 	}
@@ -25,42 +25,34 @@ import (
 	"github.com/sanathkr/go-yaml"
 )
 
+// extraProperties is a resource Metadata entry holding properties goformation doesn't support yet. Those are merged
+// into resource Properties by marshall.
+const extraProperties = "x-compose-properties"
+
+// marshall renders template, fixing up the YAML tree for properties goformation can't express, before converting to
+// the requested format
 func marshall(template *cloudformation.Template, format string) ([]byte, error) {
-	var (
-		source    func() ([]byte, error)
-		marshal   func(in interface{}) ([]byte, error)
-		unmarshal func(in []byte, out interface{}) error
-	)
-	switch format {
-	case "yaml":
-		source = template.YAML
-		marshal = yaml.Marshal
-		unmarshal = yaml.Unmarshal
-	case "json":
-		source = template.JSON
-		marshal = func(in interface{}) ([]byte, error) {
-			return json.MarshalIndent(in, "", "  ")
-		}
-		unmarshal = json.Unmarshal
-	default:
+	if format != "yaml" && format != "json" {
 		return nil, fmt.Errorf("unsupported format %q", format)
 	}
 
-	raw, err := source()
+	raw, err := template.YAML()
 	if err != nil {
 		return nil, err
 	}
 
 	var unmarshalled interface{}
-	if err := unmarshal(raw, &unmarshalled); err != nil {
-		return nil, fmt.Errorf("invalid JSON: %s", err)
+	if err := yaml.Unmarshal(raw, &unmarshalled); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %s", err)
 	}
 
 	if input, ok := unmarshalled.(map[interface{}]interface{}); ok {
 		if resources, ok := input["Resources"]; ok {
 			for _, uresource := range resources.(map[interface{}]interface{}) {
 				if resource, ok := uresource.(map[interface{}]interface{}); ok {
-					if resource["Type"] == "AWS::ECS::TaskDefinition" {
+					mergeExtraProperties(resource)
+					switch resource["Type"] {
+					case "AWS::ECS::TaskDefinition":
 						properties := resource["Properties"].(map[interface{}]interface{})
 						for _, def := range properties["ContainerDefinitions"].([]interface{}) {
 							containerDefinition := def.(map[interface{}]interface{})
@@ -68,11 +60,103 @@ func marshall(template *cloudformation.Template, format string) ([]byte, error)
 								containerDefinition["Essential"] = false
 							}
 						}
+					case "AWS::ApplicationAutoScaling::ScalableTarget":
+						restoreZeroCapacities(resource["Properties"].(map[interface{}]interface{}))
 					}
 				}
 			}
 		}
 	}
 
-	return marshal(unmarshalled)
+	if format == "json" {
+		return json.MarshalIndent(toJSONCompatible(unmarshalled), "", "  ")
+	}
+	return yaml.Marshal(unmarshalled)
+}
+
+// toJSONCompatible converts YAML maps, which can have any key type, to JSON objects
+func toJSONCompatible(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[interface{}]interface{}:
+		out := map[string]interface{}{}
+		for key, value := range v {
+			out[fmt.Sprint(key)] = toJSONCompatible(value)
+		}
+		return out
+	case []interface{}:
+		for i, value := range v {
+			v[i] = toJSONCompatible(value)
+		}
+		return v
+	default:
+		return in
+	}
+}
+
+func mergeExtraProperties(resource map[interface{}]interface{}) {
+	metadata, ok := resource["Metadata"].(map[interface{}]interface{})
+	if !ok {
+		return
+	}
+	extra, ok := metadata[extraProperties]
+	if !ok {
+		return
+	}
+	resource["Properties"] = mergeProperties(resource["Properties"], extra)
+	delete(metadata, extraProperties)
+	if len(metadata) == 0 {
+		delete(resource, "Metadata")
+	}
+}
+
+// mergeProperties merges src into dst. Lists are merged item by item, so that a property can be set on a specific
+// list item, like a container definition
+func mergeProperties(dst interface{}, src interface{}) interface{} {
+	switch s := src.(type) {
+	case map[interface{}]interface{}:
+		d, ok := dst.(map[interface{}]interface{})
+		if !ok {
+			return s
+		}
+		for key, value := range s {
+			d[key] = mergeProperties(d[key], value)
+		}
+		return d
+	case []interface{}:
+		d, ok := dst.([]interface{})
+		if !ok {
+			return s
+		}
+		for i, value := range s {
+			if i < len(d) {
+				d[i] = mergeProperties(d[i], value)
+			} else {
+				d = append(d, value)
+			}
+		}
+		return d
+	default:
+		return src
+	}
+}
+
+// restoreZeroCapacities sets capacities omitted by goformation when set to 0, so services can be scaled to zero
+func restoreZeroCapacities(target map[interface{}]interface{}) {
+	if _, ok := target["MinCapacity"]; !ok {
+		target["MinCapacity"] = 0
+	}
+	actions, _ := target["ScheduledActions"].([]interface{})
+	for _, a := range actions {
+		action := a.(map[interface{}]interface{})
+		capacities, ok := action["ScalableTargetAction"].(map[interface{}]interface{})
+		if !ok {
+			capacities = map[interface{}]interface{}{}
+			action["ScalableTargetAction"] = capacities
+		}
+		for _, key := range []string{"MinCapacity", "MaxCapacity"} {
+			if _, ok := capacities[key]; !ok {
+				capacities[key] = 0
+			}
+		}
+	}
 }
@@ -23,14 +23,6 @@ import (
 	"github.com/docker/compose/v2/pkg/api"
 )
 
-func (b *ecsAPIService) Build(ctx context.Context, project *types.Project, options api.BuildOptions) error {
-	return api.ErrNotImplemented
-}
-
-func (b *ecsAPIService) Push(ctx context.Context, project *types.Project, options api.PushOptions) error {
-	return api.ErrNotImplemented
-}
-
 func (b *ecsAPIService) Pull(ctx context.Context, project *types.Project, options api.PullOptions) error {
 	return api.ErrNotImplemented
 }
@@ -59,14 +51,6 @@ func (b *ecsAPIService) UnPause(ctx context.Context, project string, options api
 	return api.ErrNotImplemented
 }
 
-func (b *ecsAPIService) Events(ctx context.Context, project string, options api.EventsOptions) error {
-	return api.ErrNotImplemented
-}
-
-func (b *ecsAPIService) Port(ctx context.Context, project string, service string, port int, options api.PortOptions) (string, int, error) {
-	return "", 0, api.ErrNotImplemented
-}
-
 func (b *ecsAPIService) Copy(ctx context.Context, project *types.Project, options api.CopyOptions) error {
 	return api.ErrNotImplemented
 }
@@ -0,0 +1,124 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/pkg/errors"
+)
+
+const loadBalancerDNSNameOutput = "LoadBalancerDNSName"
+
+func serviceURLOutputName(service string, protocol string, port uint32) string {
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return fmt.Sprintf("%s%s%dURL", normalizeResourceName(service), strings.ToUpper(protocol), port)
+}
+
+func serviceDiscoveryOutputName(service string) string {
+	return fmt.Sprintf("%sServiceDiscoveryName", normalizeResourceName(service))
+}
+
+func exportOutputs(project *types.Project) bool {
+	export, ok := project.Extensions[extensionExportOutputs].(bool)
+	return ok && export
+}
+
+// stackOutput declares a stack output. With x-aws-export_outputs, output is exported as <stack>-<output> so other stacks
+// can use Fn::ImportValue
+func stackOutput(project *types.Project, name string, description string, value interface{}) cloudformation.Output {
+	output := cloudformation.Output{
+		Description: description,
+		Value:       value,
+	}
+	if exportOutputs(project) {
+		output.Export = &cloudformation.Export{
+			Name: cloudformation.Sub(fmt.Sprintf("${AWS::StackName}-%s", name)),
+		}
+	}
+	return output
+}
+
+// createOutputs declares stack outputs for load balancer DNS name, URL of published ports and services DNS names
+// within Cloud Map namespace
+func (b *ecsAPIService) createOutputs(project *types.Project, resources awsResources, template *cloudformation.Template) {
+	services := append(types.Services{}, project.Services...)
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	dnsName := resources.loadBalancerDNSName()
+	if dnsName != "" {
+		template.Outputs[loadBalancerDNSNameOutput] = stackOutput(project, loadBalancerDNSNameOutput,
+			"Load balancer DNS name", dnsName)
+	}
+
+	for _, service := range services {
+		if isScheduled(service) {
+			continue
+		}
+		if dnsName != "" {
+			for _, port := range service.Ports {
+				name := serviceURLOutputName(service.Name, port.Protocol, port.Target)
+				template.Outputs[name] = stackOutput(project, name,
+					fmt.Sprintf("%s service URL for port %d/%s", service.Name, port.Target, port.Protocol),
+					cloudformation.Join("", []string{dnsName, fmt.Sprintf(":%d", port.Published)}))
+			}
+		}
+		if useServiceConnect(project) {
+			continue
+		}
+		name := serviceDiscoveryOutputName(service.Name)
+		template.Outputs[name] = stackOutput(project, name,
+			fmt.Sprintf("%s service DNS name", service.Name),
+			fmt.Sprintf("%s.%s.local", service.Name, project.Name))
+	}
+}
+
+func (b *ecsAPIService) Port(ctx context.Context, project string, service string, port int, options api.PortOptions) (string, int, error) {
+	outputs, err := b.aws.ListStackOutputs(ctx, project)
+	if err != nil {
+		return "", 0, err
+	}
+	protocol := options.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	url, ok := outputs[serviceURLOutputName(service, protocol, uint32(port))]
+	if !ok {
+		return "", 0, errors.Wrapf(api.ErrNotFound, "service %q doesn't publish port %d/%s", service, port, protocol)
+	}
+	host, published, err := net.SplitHostPort(url)
+	if err != nil {
+		return "", 0, err
+	}
+	p, err := strconv.Atoi(published)
+	if err != nil {
+		return "", 0, err
+	}
+	return host, p, nil
+}
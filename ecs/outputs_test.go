@@ -0,0 +1,93 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestStackOutputs(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  front:
+    image: front
+    ports:
+      - 8080:80
+  worker:
+    image: worker
+    x-aws-schedule: "rate(1 hour)"
+`, nil, useDefaultVPC)
+	dnsName := cloudformation.GetAtt("LoadBalancer", "DNSName")
+	assert.Equal(t, template.Outputs["LoadBalancerDNSName"].Value, dnsName)
+
+	url := template.Outputs["FrontTCP80URL"]
+	assert.Equal(t, url.Value, cloudformation.Join("", []string{dnsName, ":8080"}))
+	assert.Check(t, url.Export == nil)
+
+	assert.Equal(t, template.Outputs["FrontServiceDiscoveryName"].Value, "front.TestStackOutputs.local")
+	_, ok := template.Outputs["WorkerServiceDiscoveryName"]
+	assert.Check(t, !ok)
+}
+
+func TestStackOutputsExport(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  front:
+    image: front
+    ports:
+      - 8080:80
+x-aws-export_outputs: true
+`, nil, useDefaultVPC)
+	assert.Equal(t, template.Outputs["LoadBalancerDNSName"].Export.Name, cloudformation.Sub("${AWS::StackName}-LoadBalancerDNSName"))
+	assert.Equal(t, template.Outputs["FrontTCP80URL"].Export.Name, cloudformation.Sub("${AWS::StackName}-FrontTCP80URL"))
+}
+
+func TestStackOutputsWithoutLoadBalancer(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  worker:
+    image: worker
+`, nil, useDefaultVPC)
+	_, ok := template.Outputs["LoadBalancerDNSName"]
+	assert.Check(t, !ok)
+	assert.Equal(t, len(template.Outputs), 1)
+}
+
+func TestPort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	m.EXPECT().ListStackOutputs(gomock.Any(), "test").Return(map[string]string{
+		"LoadBalancerDNSName": "test-lb-1234.elb.us-east-1.amazonaws.com",
+		"FrontTCP80URL":       "test-lb-1234.elb.us-east-1.amazonaws.com:8080",
+	}, nil).Times(2)
+
+	backend := &ecsAPIService{aws: m}
+	host, port, err := backend.Port(context.TODO(), "test", "front", 80, api.PortOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, host, "test-lb-1234.elb.us-east-1.amazonaws.com")
+	assert.Equal(t, port, 8080)
+
+	_, _, err = backend.Port(context.TODO(), "test", "front", 80, api.PortOptions{Protocol: "udp"})
+	assert.Check(t, api.IsNotFoundError(err))
+}
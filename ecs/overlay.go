@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/merge2"
+)
+
+// checkOverlayResources reports x-aws-cloudformation overlay resources which don't match a resource from template.
+// Overlay can declare additional resources, which then must set a Type
+func checkOverlayResources(template *cloudformation.Template, overlay interface{}) error {
+	x, ok := overlay.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s must be a mapping", extensionCloudFormation)
+	}
+	resources, ok := x["Resources"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var unknown []string
+	for id, r := range resources {
+		if _, ok := template.Resources[id]; ok {
+			continue
+		}
+		if resource, ok := r.(map[string]interface{}); ok && resource["Type"] != nil {
+			continue
+		}
+		unknown = append(unknown, id)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("%s overlay references unknown resources: %s", extensionCloudFormation, strings.Join(unknown, ", "))
+}
+
+// applyOverlay merges x-aws-cloudformation overlay into the YAML template, then renders it using the requested format
+func applyOverlay(template []byte, overlay interface{}, format string) ([]byte, error) {
+	if format != "yaml" && format != "json" {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	nodes, err := yaml.Parse(string(template))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := yaml.Marshal(overlay)
+	if err != nil {
+		return nil, err
+	}
+	x, err := yaml.Parse(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	nodes, err = merge2.Merge(x, nodes, yaml.MergeOptions{
+		ListIncreaseDirection: yaml.MergeOptionsListPrepend,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "json" {
+		raw, err := nodes.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		err = json.Indent(&out, raw, "", "  ")
+		return out.Bytes(), err
+	}
+	s, err := nodes.String()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
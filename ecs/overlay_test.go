@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func renderYaml(t *testing.T, yaml string, format string) ([]byte, error) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	useDefaultVPC(m.EXPECT())
+
+	backend := &ecsAPIService{aws: m}
+	return backend.render(context.TODO(), loadConfig(t, yaml), format)
+}
+
+func TestOverlayJSON(t *testing.T) {
+	out, err := renderYaml(t, `
+services:
+  foo:
+    image: hello_world@sha256:e0c3d3ba6b5b1b8ab6e9e3d3cf1d7d0e64d4d5c3c3e1c38c1cc1b6b2ab1e9a3d
+x-aws-cloudformation:
+  Resources:
+    FooService:
+      Properties:
+        PropagateTags: SERVICE
+    Bucket:
+      Type: AWS::S3::Bucket
+`, "json")
+	assert.NilError(t, err)
+
+	var template struct {
+		Resources map[string]struct {
+			Type       string                 `json:"Type"`
+			Properties map[string]interface{} `json:"Properties"`
+		} `json:"Resources"`
+	}
+	assert.NilError(t, json.Unmarshal(out, &template))
+	service := template.Resources["FooService"]
+	assert.Equal(t, service.Type, "AWS::ECS::Service")
+	assert.Equal(t, service.Properties["PropagateTags"], "SERVICE")
+	assert.Equal(t, service.Properties["LaunchType"], "FARGATE")
+	assert.Equal(t, template.Resources["Bucket"].Type, "AWS::S3::Bucket")
+}
+
+func TestOverlayUnknownResources(t *testing.T) {
+	_, err := renderYaml(t, `
+services:
+  foo:
+    image: hello_world@sha256:e0c3d3ba6b5b1b8ab6e9e3d3cf1d7d0e64d4d5c3c3e1c38c1cc1b6b2ab1e9a3d
+x-aws-cloudformation:
+  Resources:
+    FooSevrice:
+      Properties:
+        PropagateTags: SERVICE
+    FooTaskDefinition:
+      Properties:
+        Cpu: 512
+    LogGruop:
+      Properties:
+        RetentionInDays: 7
+`, "yaml")
+	assert.Error(t, err, "x-aws-cloudformation overlay references unknown resources: FooSevrice, LogGruop")
+}
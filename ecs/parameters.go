@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/compose-spec/compose-go/types"
+)
+
+const (
+	secretSourceSSM            = "ssm"
+	secretSourceSecretsManager = "secretsmanager"
+)
+
+// getSecretSource tells if secret is stored in Secrets Manager or as a SSM Parameter Store SecureString, according to
+// x-aws-secret-source, then secret ARN. As Secrets Manager secrets must be referenced by ARN, external secrets set
+// by name are considered SSM parameters.
+func getSecretSource(secret types.SecretConfig) string {
+	if source, ok := secret.Extensions[extensionSecretSource].(string); ok {
+		return source
+	}
+	if !secret.External.External {
+		return secretSourceSecretsManager
+	}
+	if parsed, err := arn.Parse(secret.Name); err == nil && parsed.Service == secretSourceSSM {
+		return secretSourceSSM
+	}
+	if strings.HasPrefix(secret.Name, "arn:") {
+		return secretSourceSecretsManager
+	}
+	return secretSourceSSM
+}
+
+func checkSecretSource(name string, secret types.SecretConfig) error {
+	v, ok := secret.Extensions[extensionSecretSource]
+	if !ok {
+		return nil
+	}
+	if !secret.External.External {
+		return fmt.Errorf("secret %s: %s can only be set on external secrets", name, extensionSecretSource)
+	}
+	switch v {
+	case secretSourceSSM, secretSourceSecretsManager:
+		return nil
+	default:
+		return fmt.Errorf("secret %s: %s must be %q or %q", name, extensionSecretSource, secretSourceSSM, secretSourceSecretsManager)
+	}
+}
+
+// ssmParameterARN returns the ARN of a SSM parameter set by name or ARN, as required to grant access by IAM policies
+func ssmParameterARN(parameter string) string {
+	if strings.HasPrefix(parameter, "arn:") {
+		return parameter
+	}
+	return cloudformation.Sub(fmt.Sprintf("arn:${AWS::Partition}:ssm:${AWS::Region}:${AWS::AccountId}:parameter/%s", strings.TrimPrefix(parameter, "/")))
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/iam"
+	"gotest.tools/v3/assert"
+)
+
+func TestSSMParameterSecrets(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  test:
+    image: nginx
+    secrets:
+      - by_name
+      - by_arn
+      - by_source
+      - secrets_manager
+secrets:
+  by_name:
+    external: true
+    name: /company/db_password
+  by_arn:
+    external: true
+    name: arn:aws:ssm:eu-west-3:012345678910:parameter/company/api_key
+  by_source:
+    external: true
+    name: arn:aws:secretsmanager:eu-west-3:012345678910:secret:token-AbCdEf
+    x-aws-secret-source: secretsmanager
+  secrets_manager:
+    external: true
+    name: arn:aws:secretsmanager:eu-west-3:012345678910:secret:other-AbCdEf
+`, nil, useDefaultVPC)
+	def := template.Resources["TestTaskDefinition"].(*ecs.TaskDefinition)
+	sidecar := def.ContainerDefinitions[0]
+	assert.Equal(t, sidecar.Secrets[0].ValueFrom, "/company/db_password")
+
+	role := template.Resources["TestTaskExecutionRole"].(*iam.Role)
+	policy := role.Policies[0].PolicyDocument.(*PolicyDocument)
+	assert.DeepEqual(t, policy.Statement[0].Resource, []string{
+		cloudformation.Sub("arn:${AWS::Partition}:ssm:${AWS::Region}:${AWS::AccountId}:parameter/company/db_password"),
+		"arn:aws:ssm:eu-west-3:012345678910:parameter/company/api_key",
+		"arn:aws:secretsmanager:eu-west-3:012345678910:secret:token-AbCdEf",
+		"arn:aws:secretsmanager:eu-west-3:012345678910:secret:other-AbCdEf",
+	})
+	assert.DeepEqual(t, policy.Statement[1].Action, []string{actionDecrypt})
+	assert.DeepEqual(t, policy.Statement[1].Condition.StringEquals, map[string]string{
+		"kms:ViaService": cloudformation.Sub("ssm.${AWS::Region}.amazonaws.com"),
+	})
+}
+
+func TestSecretsManagerOnly(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  test:
+    image: nginx
+    secrets:
+      - token
+secrets:
+  token:
+    external: true
+    name: arn:aws:secretsmanager:eu-west-3:012345678910:secret:token-AbCdEf
+`, nil, useDefaultVPC)
+	role := template.Resources["TestTaskExecutionRole"].(*iam.Role)
+	policy := role.Policies[0].PolicyDocument.(*PolicyDocument)
+	assert.Equal(t, len(policy.Statement), 1)
+}
+
+func TestInvalidSecretSource(t *testing.T) {
+	convertYaml(t, `
+services:
+  test:
+    image: nginx
+    secrets:
+      - token
+secrets:
+  token:
+    external: true
+    name: token
+    x-aws-secret-source: vault
+`, errors.New(`secret token: x-aws-secret-source must be "ssm" or "secretsmanager"`), useDefaultVPC)
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/compose/v2/cmd/formatter"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/sanathkr/go-yaml"
+	"github.com/sirupsen/logrus"
+)
+
+func isDryRun(project *types.Project) bool {
+	dryRun, ok := project.Extensions[ExtensionDryRun].(bool)
+	return ok && dryRun
+}
+
+// dryRun renders changes `up` would apply to the CloudFormation stack, without executing them
+func (b *ecsAPIService) dryRun(ctx context.Context, project *types.Project, w io.Writer) error {
+	template, err := b.Convert(ctx, project, api.ConvertOptions{
+		Format: "yaml",
+	})
+	if err != nil {
+		return err
+	}
+
+	changes, err := b.previewChanges(ctx, project.Name, template)
+	if err != nil {
+		return err
+	}
+	return printStackChanges(w, changes)
+}
+
+// previewChanges computes resource-level changes to the stack. An existing stack is compared to template using a
+// change set, which is deleted once described. Without an existing stack, all resources from template will be added.
+func (b *ecsAPIService) previewChanges(ctx context.Context, name string, template []byte) ([]stackChange, error) {
+	update, err := b.aws.StackExists(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !update {
+		return templateResources(template)
+	}
+
+	changeset, err := b.aws.CreateChangeSet(ctx, name, b.Region, template)
+	if changeset != "" {
+		defer func() {
+			if err := b.aws.DeleteChangeSet(ctx, changeset); err != nil {
+				logrus.Warnf("failed to delete change set %s: %v", changeset, err)
+			}
+		}()
+	}
+	if err != nil {
+		if strings.HasPrefix(err.Error(), noChangesReason) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return b.aws.DescribeChangeSet(ctx, changeset)
+}
+
+func templateResources(template []byte) ([]stackChange, error) {
+	var parsed struct {
+		Resources map[string]struct {
+			Type string `yaml:"Type"`
+		} `yaml:"Resources"`
+	}
+	if err := yaml.Unmarshal(template, &parsed); err != nil {
+		return nil, err
+	}
+	var changes []stackChange
+	for id, resource := range parsed.Resources {
+		changes = append(changes, stackChange{
+			Action:    cloudformation.ChangeActionAdd,
+			LogicalID: id,
+			Type:      resource.Type,
+		})
+	}
+	return changes, nil
+}
+
+func printStackChanges(w io.Writer, changes []stackChange) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes")
+		return err
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].LogicalID < changes[j].LogicalID
+	})
+	count := map[string]int{}
+	err := formatter.PrintPrettySection(w, func(w io.Writer) {
+		for _, c := range changes {
+			count[c.Action]++
+			replacement := c.Replacement
+			if c.Action != cloudformation.ChangeActionModify {
+				replacement = "-"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Action, c.LogicalID, c.Type, replacement)
+		}
+	}, "ACTION", "RESOURCE", "TYPE", "REPLACEMENT")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\n%d to add, %d to modify, %d to remove\n",
+		count[cloudformation.ChangeActionAdd], count[cloudformation.ChangeActionModify], count[cloudformation.ChangeActionRemove])
+	return err
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+const previewTemplate = `
+Resources:
+  LoadBalancer:
+    Type: AWS::ElasticLoadBalancingV2::LoadBalancer
+  FooService:
+    Type: AWS::ECS::Service
+`
+
+func TestPreviewUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	m.EXPECT().StackExists(gomock.Any(), "test").Return(true, nil)
+	m.EXPECT().CreateChangeSet(gomock.Any(), "test", "region", []byte(previewTemplate)).Return("changeset", nil)
+	m.EXPECT().DescribeChangeSet(gomock.Any(), "changeset").Return([]stackChange{
+		{Action: "Modify", LogicalID: "LoadBalancer", Type: "AWS::ElasticLoadBalancingV2::LoadBalancer", Replacement: "True"},
+		{Action: "Remove", LogicalID: "BarService", Type: "AWS::ECS::Service"},
+		{Action: "Add", LogicalID: "FooService", Type: "AWS::ECS::Service"},
+	}, nil)
+	m.EXPECT().DeleteChangeSet(gomock.Any(), "changeset").Return(nil)
+
+	backend := &ecsAPIService{aws: m, Region: "region"}
+	changes, err := backend.previewChanges(context.TODO(), "test", []byte(previewTemplate))
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, printStackChanges(&out, changes))
+	assert.Equal(t, out.String(), `ACTION              RESOURCE            TYPE                                        REPLACEMENT
+Remove              BarService          AWS::ECS::Service                           -
+Add                 FooService          AWS::ECS::Service                           -
+Modify              LoadBalancer        AWS::ElasticLoadBalancingV2::LoadBalancer   True
+
+1 to add, 1 to modify, 1 to remove
+`)
+}
+
+func TestPreviewNoChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	m.EXPECT().StackExists(gomock.Any(), "test").Return(true, nil)
+	m.EXPECT().CreateChangeSet(gomock.Any(), "test", "region", gomock.Any()).
+		Return("changeset", errors.New("The submitted information didn't contain changes. Submit different information to create a change set."))
+	m.EXPECT().DeleteChangeSet(gomock.Any(), "changeset").Return(nil)
+
+	backend := &ecsAPIService{aws: m, Region: "region"}
+	changes, err := backend.previewChanges(context.TODO(), "test", []byte(previewTemplate))
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, printStackChanges(&out, changes))
+	assert.Equal(t, out.String(), "No changes\n")
+}
+
+func TestPreviewCreate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	m.EXPECT().StackExists(gomock.Any(), "test").Return(false, nil)
+
+	backend := &ecsAPIService{aws: m, Region: "region"}
+	changes, err := backend.previewChanges(context.TODO(), "test", []byte(previewTemplate))
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 2)
+	for _, c := range changes {
+		assert.Equal(t, c.Action, "Add")
+	}
+}
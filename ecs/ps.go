@@ -18,17 +18,13 @@ package ecs
 
 import (
 	"context"
+	"strings"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/docker/compose/v2/pkg/api"
-
-	"github.com/docker/compose-cli/utils"
 )
 
 func (b *ecsAPIService) Ps(ctx context.Context, projectName string, options api.PsOptions) ([]api.ContainerSummary, error) {
-	if err := checkUnsupportedPsOptions(ctx, options); err != nil {
-		return nil, err
-	}
-
 	cluster, err := b.aws.GetStackClusterID(ctx, projectName)
 	if err != nil {
 		return nil, err
@@ -38,7 +34,12 @@ func (b *ecsAPIService) Ps(ctx context.Context, projectName string, options api.
 		return nil, err
 	}
 
-	if len(servicesARN) == 0 {
+	scheduled, err := b.listScheduledServices(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(servicesARN) == 0 && len(scheduled) == 0 {
 		return nil, nil
 	}
 
@@ -49,20 +50,69 @@ func (b *ecsAPIService) Ps(ctx context.Context, projectName string, options api.
 			return nil, err
 		}
 
-		tasks, err := b.aws.DescribeServiceTasks(ctx, cluster, projectName, service.Name)
+		tasks, err := b.serviceTasks(ctx, cluster, projectName, service.Name, service.Publishers, options.All)
 		if err != nil {
 			return nil, err
 		}
+		summary = append(summary, tasks...)
+	}
 
-		for i, t := range tasks {
-			t.Publishers = service.Publishers
-			tasks[i] = t
+	for _, service := range scheduled {
+		tasks, err := b.serviceTasks(ctx, cluster, projectName, service, nil, options.All)
+		if err != nil {
+			return nil, err
 		}
 		summary = append(summary, tasks...)
 	}
 	return summary, nil
 }
 
-func checkUnsupportedPsOptions(ctx context.Context, o api.PsOptions) error {
-	return utils.CheckUnsupported(ctx, nil, o.All, false, "ps", "all")
+// serviceTasks lists service's running tasks, exposed by publishers, and tasks which recently stopped when all is set.
+// ECS only keeps track of stopped tasks for about an hour
+func (b *ecsAPIService) serviceTasks(ctx context.Context, cluster string, projectName string, service string,
+	publishers api.PortPublishers, all bool) ([]api.ContainerSummary, error) {
+	tasks, err := b.aws.DescribeServiceTasks(ctx, cluster, projectName, service, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i].Publishers = publishers
+	}
+	if !all {
+		return tasks, nil
+	}
+	stopped, err := b.aws.DescribeServiceTasks(ctx, cluster, projectName, service, true)
+	if err != nil {
+		return nil, err
+	}
+	return append(tasks, stopped...), nil
+}
+
+// listScheduledServices returns the name of services running as scheduled tasks, based on the family of task
+// definitions targeted by stack's EventBridge rules
+func (b *ecsAPIService) listScheduledServices(ctx context.Context, projectName string) ([]string, error) {
+	resources, err := b.aws.ListStackResources(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	taskDefinitions := map[string]string{}
+	for _, r := range resources {
+		if r.Type == "AWS::ECS::TaskDefinition" {
+			taskDefinitions[r.LogicalID] = r.ARN
+		}
+	}
+	var services []string
+	for _, r := range resources {
+		if r.Type != "AWS::Events::Rule" || !strings.HasSuffix(r.LogicalID, "ScheduleRule") {
+			continue
+		}
+		taskDefinition, err := arn.Parse(taskDefinitions[strings.TrimSuffix(r.LogicalID, "ScheduleRule")+"TaskDefinition"])
+		if err != nil {
+			continue
+		}
+		// task definition resource is "task-definition/<project>-<service>:<revision>"
+		family := strings.SplitN(strings.TrimPrefix(taskDefinition.Resource, "task-definition/"), ":", 2)[0]
+		services = append(services, strings.TrimPrefix(family, projectName+"-"))
+	}
+	return services, nil
 }
@@ -0,0 +1,78 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestPsAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	publishers := api.PortPublishers{{URL: "lb", TargetPort: 80, PublishedPort: 80, Protocol: "tcp"}}
+	m.EXPECT().GetStackClusterID(gomock.Any(), "test").Return("cluster", nil)
+	m.EXPECT().ListStackServices(gomock.Any(), "test").Return([]string{"web"}, nil)
+	m.EXPECT().ListStackResources(gomock.Any(), "test").Return(stackResources{}, nil)
+	m.EXPECT().DescribeService(gomock.Any(), "cluster", "web").Return(api.ServiceStatus{Name: "web", Publishers: publishers}, nil)
+	m.EXPECT().DescribeServiceTasks(gomock.Any(), "cluster", "test", "web", false).Return([]api.ContainerSummary{
+		{Name: "running", Service: "web", State: "Running"},
+	}, nil)
+	m.EXPECT().DescribeServiceTasks(gomock.Any(), "cluster", "test", "web", true).Return([]api.ContainerSummary{
+		{Name: "crashed", Service: "web", State: "exited", ExitCode: 1},
+	}, nil)
+
+	backend := &ecsAPIService{aws: m}
+	containers, err := backend.Ps(context.TODO(), "test", api.PsOptions{All: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(containers), 2)
+	assert.DeepEqual(t, containers[0].Publishers, publishers)
+	assert.Equal(t, containers[1].Name, "crashed")
+	assert.Equal(t, containers[1].ExitCode, 1)
+	assert.Check(t, containers[1].Publishers == nil)
+}
+
+func TestStoppedTaskSummary(t *testing.T) {
+	stoppedAt := time.Now()
+	summary, err := taskSummary(&ecs.Task{
+		TaskArn:       aws.String("arn:aws:ecs:us-east-1:123456789012:task/cluster/0123456789"),
+		LastStatus:    aws.String("STOPPED"),
+		StopCode:      aws.String("EssentialContainerExited"),
+		StoppedReason: aws.String("Essential container in task exited"),
+		StoppedAt:     &stoppedAt,
+		Containers: []*ecs.Container{
+			{Name: aws.String("Web_ResolvConf_InitContainer"), ExitCode: aws.Int64(0)},
+			{Name: aws.String("web"), ExitCode: aws.Int64(137)},
+		},
+		Tags: []*ecs.Tag{
+			{Key: aws.String(api.ServiceLabel), Value: aws.String("web")},
+		},
+	}, "test", "")
+	assert.NilError(t, err)
+	assert.Equal(t, summary.Service, "web")
+	assert.Equal(t, summary.Name, "task/cluster/0123456789")
+	assert.Equal(t, summary.ExitCode, 137)
+	assert.Equal(t, summary.State, "exited")
+}
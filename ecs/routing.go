@@ -0,0 +1,223 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/elasticloadbalancingv2"
+	"github.com/compose-spec/compose-go/types"
+)
+
+const maxListenerRulePriority = 50000
+
+// loadBalancerRule configures host and path based routing to a service sharing an Application Load Balancer listener
+type loadBalancerRule struct {
+	Host     string `json:"host,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+func getLoadBalancerRule(service types.ServiceConfig) (*loadBalancerRule, error) {
+	v, ok := service.Extensions[extensionLoadBalancerRule]
+	if !ok {
+		return nil, nil
+	}
+	marshalled, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var rule loadBalancerRule
+	err = json.Unmarshal(marshalled, &rule)
+	if err != nil {
+		return nil, fmt.Errorf("service %s has an invalid %s: %w", service.Name, extensionLoadBalancerRule, err)
+	}
+	if rule.Host == "" && rule.Path == "" {
+		return nil, fmt.Errorf("service %s: %s MUST define a host or a path", service.Name, extensionLoadBalancerRule)
+	}
+	// priority is assigned automatically when not set, but an explicit value must be a valid listener rule priority
+	x, _ := v.(map[string]interface{})
+	_, explicit := x["priority"]
+	if explicit && (rule.Priority < 1 || rule.Priority > maxListenerRulePriority) {
+		return nil, fmt.Errorf("service %s: %s priority must be between 1 and %d", service.Name, extensionLoadBalancerRule, maxListenerRulePriority)
+	}
+	return &rule, nil
+}
+
+// getLoadBalancerRules collects routing rules declared by services, and assign a priority to those which don't set one
+func getLoadBalancerRules(project *types.Project) (map[string]loadBalancerRule, error) {
+	rules := map[string]loadBalancerRule{}
+	used := map[int]string{}
+	var unset []string
+	// services are processed by name, so that priority assignment doesn't depend on compose file ordering
+	services := append(types.Services{}, project.Services...)
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	for _, service := range services {
+		rule, err := getLoadBalancerRule(service)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			continue
+		}
+		rules[service.Name] = *rule
+		if rule.Priority == 0 {
+			unset = append(unset, service.Name)
+			continue
+		}
+		if other, ok := used[rule.Priority]; ok {
+			return nil, fmt.Errorf("services %s and %s use the same %s priority %d", other, service.Name, extensionLoadBalancerRule, rule.Priority)
+		}
+		used[rule.Priority] = service.Name
+	}
+
+	priority := 1
+	for _, name := range unset {
+		for used[priority] != "" {
+			priority++
+		}
+		rule := rules[name]
+		rule.Priority = priority
+		rules[name] = rule
+		used[priority] = name
+	}
+	return rules, nil
+}
+
+// isRoutedPort checks if any service relies on routing rules for the given port, so the listener must be shared
+func isRoutedPort(project *types.Project, port types.ServicePortConfig) bool {
+	for _, service := range project.Services {
+		if _, ok := service.Extensions[extensionLoadBalancerRule]; !ok {
+			continue
+		}
+		for _, p := range service.Ports {
+			if p.Published == port.Published && p.Protocol == port.Protocol {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sharedListenerResourceName(port types.ServicePortConfig) string {
+	return fmt.Sprintf(
+		"LoadBalancer%s%dListener",
+		strings.ToUpper(port.Protocol),
+		port.Published,
+	)
+}
+
+// createSharedListener declares a listener for services sharing the same port on load balancer. Requests which don't
+// match any routing rule are forwarded to the service which doesn't declare one, or are rejected with a 404 status code
+func (b *ecsAPIService) createSharedListener(service types.ServiceConfig, port types.ServicePortConfig,
+	template *cloudformation.Template,
+	targetGroupName string, loadBalancer awsResource, protocol string, routed bool) (string, error) {
+	listenerName := sharedListenerResourceName(port)
+	listener, ok := template.Resources[listenerName].(*elasticloadbalancingv2.Listener)
+	if !ok {
+		listener = &elasticloadbalancingv2.Listener{
+			DefaultActions: []elasticloadbalancingv2.Listener_Action{
+				{
+					FixedResponseConfig: &elasticloadbalancingv2.Listener_FixedResponseConfig{
+						ContentType: "text/plain",
+						StatusCode:  "404",
+					},
+					Type: elbv2.ActionTypeEnumFixedResponse,
+				},
+			},
+			LoadBalancerArn: loadBalancer.ARN(),
+			Protocol:        protocol,
+			Port:            int(port.Published),
+		}
+		template.Resources[listenerName] = listener
+	}
+	if routed {
+		return listenerName, nil
+	}
+
+	if listener.DefaultActions[0].Type == elbv2.ActionTypeEnumForward {
+		return "", fmt.Errorf("port %d is already used as default route by another service, %s must set %s", port.Published, service.Name, extensionLoadBalancerRule)
+	}
+	listener.DefaultActions = []elasticloadbalancingv2.Listener_Action{
+		{
+			ForwardConfig: &elasticloadbalancingv2.Listener_ForwardConfig{
+				TargetGroups: []elasticloadbalancingv2.Listener_TargetGroupTuple{
+					{
+						TargetGroupArn: cloudformation.Ref(targetGroupName),
+					},
+				},
+			},
+			Type: elbv2.ActionTypeEnumForward,
+		},
+	}
+	return listenerName, nil
+}
+
+func (b *ecsAPIService) createListenerRule(service types.ServiceConfig, port types.ServicePortConfig,
+	template *cloudformation.Template,
+	listenerName string, targetGroupName string, rule loadBalancerRule) string {
+	ruleName := fmt.Sprintf(
+		"%s%s%dListenerRule",
+		normalizeResourceName(service.Name),
+		strings.ToUpper(port.Protocol),
+		port.Published,
+	)
+
+	var conditions []elasticloadbalancingv2.ListenerRule_RuleCondition
+	if rule.Host != "" {
+		conditions = append(conditions, elasticloadbalancingv2.ListenerRule_RuleCondition{
+			Field: "host-header",
+			HostHeaderConfig: &elasticloadbalancingv2.ListenerRule_HostHeaderConfig{
+				Values: []string{rule.Host},
+			},
+		})
+	}
+	if rule.Path != "" {
+		conditions = append(conditions, elasticloadbalancingv2.ListenerRule_RuleCondition{
+			Field: "path-pattern",
+			PathPatternConfig: &elasticloadbalancingv2.ListenerRule_PathPatternConfig{
+				Values: []string{rule.Path},
+			},
+		})
+	}
+
+	template.Resources[ruleName] = &elasticloadbalancingv2.ListenerRule{
+		Actions: []elasticloadbalancingv2.ListenerRule_Action{
+			{
+				ForwardConfig: &elasticloadbalancingv2.ListenerRule_ForwardConfig{
+					TargetGroups: []elasticloadbalancingv2.ListenerRule_TargetGroupTuple{
+						{
+							TargetGroupArn: cloudformation.Ref(targetGroupName),
+						},
+					},
+				},
+				Type: elbv2.ActionTypeEnumForward,
+			},
+		},
+		Conditions:  conditions,
+		ListenerArn: cloudformation.Ref(listenerName),
+		Priority:    rule.Priority,
+	}
+	return ruleName
+}
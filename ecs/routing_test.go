@@ -0,0 +1,131 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/elasticloadbalancingv2"
+	"gotest.tools/v3/assert"
+)
+
+func TestLoadBalancerRules(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  api:
+    image: api
+    ports:
+      - 80:80
+    x-aws-alb-rule:
+      path: /api/*
+  front:
+    image: front
+    ports:
+      - 80:80
+    x-aws-alb-rule:
+      host: www.example.com
+      priority: 10
+`, nil, useDefaultVPC)
+	for _, r := range []string{"ApiTCP80Listener", "FrontTCP80Listener"} {
+		assert.Check(t, template.Resources[r] == nil)
+	}
+	listener := template.Resources["LoadBalancerTCP80Listener"].(*elasticloadbalancingv2.Listener)
+	assert.Equal(t, listener.Port, 80)
+	assert.Equal(t, listener.DefaultActions[0].Type, elbv2.ActionTypeEnumFixedResponse)
+
+	rule := template.Resources["ApiTCP80ListenerRule"].(*elasticloadbalancingv2.ListenerRule)
+	assert.Equal(t, rule.Priority, 1)
+	assert.Equal(t, rule.ListenerArn, cloudformation.Ref("LoadBalancerTCP80Listener"))
+	assert.DeepEqual(t, rule.Conditions[0].PathPatternConfig.Values, []string{"/api/*"})
+	assert.Equal(t, rule.Actions[0].ForwardConfig.TargetGroups[0].TargetGroupArn, cloudformation.Ref("ApiTCP80TargetGroup"))
+
+	rule = template.Resources["FrontTCP80ListenerRule"].(*elasticloadbalancingv2.ListenerRule)
+	assert.Equal(t, rule.Priority, 10)
+	assert.DeepEqual(t, rule.Conditions[0].HostHeaderConfig.Values, []string{"www.example.com"})
+
+	service := template.Resources["ApiService"].(*ecs.Service)
+	assert.DeepEqual(t, service.AWSCloudFormationDependsOn, []string{"LoadBalancerTCP80Listener", "ApiTCP80ListenerRule"})
+}
+
+func TestLoadBalancerDefaultRoute(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  api:
+    image: api
+    ports:
+      - 80:80
+    x-aws-alb-rule:
+      path: /api/*
+  front:
+    image: front
+    ports:
+      - 80:80
+`, nil, useDefaultVPC)
+	listener := template.Resources["LoadBalancerTCP80Listener"].(*elasticloadbalancingv2.Listener)
+	assert.Equal(t, listener.DefaultActions[0].Type, elbv2.ActionTypeEnumForward)
+	assert.Equal(t, listener.DefaultActions[0].ForwardConfig.TargetGroups[0].TargetGroupArn, cloudformation.Ref("FrontTCP80TargetGroup"))
+	assert.Check(t, template.Resources["FrontTCP80ListenerRule"] == nil)
+}
+
+func TestLoadBalancerRulesConflict(t *testing.T) {
+	convertYaml(t, `
+services:
+  api:
+    image: api
+    ports:
+      - 80:80
+    x-aws-alb-rule:
+      path: /api/*
+      priority: 5
+  front:
+    image: front
+    ports:
+      - 80:80
+    x-aws-alb-rule:
+      path: /*
+      priority: 5
+`, errors.New("services api and front use the same x-aws-alb-rule priority 5"), useDefaultVPC)
+}
+
+func TestLoadBalancerRulesInvalidPriority(t *testing.T) {
+	convertYaml(t, `
+services:
+  api:
+    image: api
+    ports:
+      - 80:80
+    x-aws-alb-rule:
+      path: /api/*
+      priority: 0
+`, errors.New("service api: x-aws-alb-rule priority must be between 1 and 50000"), useDefaultVPC)
+}
+
+func TestLoadBalancerRulesRequireALB(t *testing.T) {
+	convertYaml(t, `
+services:
+  api:
+    image: api
+    ports:
+      - 8080:8080
+    x-aws-alb-rule:
+      path: /api/*
+`, errors.New("service api: x-aws-alb-rule requires an Application Load Balancer"), useDefaultVPC)
+}
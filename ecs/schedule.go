@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/events"
+	"github.com/awslabs/goformation/v4/cloudformation/iam"
+	"github.com/compose-spec/compose-go/types"
+)
+
+func isScheduled(service types.ServiceConfig) bool {
+	_, ok := service.Extensions[extensionSchedule]
+	return ok
+}
+
+func scheduleRuleResourceName(service string) string {
+	return fmt.Sprintf("%sScheduleRule", normalizeResourceName(service))
+}
+
+func getScheduleExpression(service types.ServiceConfig) (string, error) {
+	expression, ok := service.Extensions[extensionSchedule].(string)
+	if !ok || !(strings.HasPrefix(expression, "cron(") || strings.HasPrefix(expression, "rate(")) || !strings.HasSuffix(expression, ")") {
+		return "", fmt.Errorf("service %s: %s must be a cron() or rate() schedule expression", service.Name, extensionSchedule)
+	}
+	return expression, nil
+}
+
+// createScheduledTask declares an EventBridge rule to run service's task definition on schedule, as an alternative
+// to an ECS service keeping tasks running
+func (b *ecsAPIService) createScheduledTask(project *types.Project, service types.ServiceConfig, template *cloudformation.Template,
+	resources awsResources, taskDefinition string, taskExecutionRole string, taskRole string) error {
+	expression, err := getScheduleExpression(service)
+	if err != nil {
+		return err
+	}
+	if len(service.Ports) > 0 {
+		return fmt.Errorf("service %s: %s can't be used with ports", service.Name, extensionSchedule)
+	}
+	if _, ok := service.Extensions[extensionCapacity]; ok {
+		return fmt.Errorf("service %s: %s can't be used with %s", service.Name, extensionSchedule, extensionCapacity)
+	}
+	if service.Deploy != nil {
+		if _, ok := service.Deploy.Extensions[extensionAutoScaling]; ok {
+			return fmt.Errorf("service %s: %s can't be used with %s", service.Name, extensionSchedule, extensionAutoScaling)
+		}
+	}
+
+	roles := []string{cloudformation.GetAtt(taskExecutionRole, "Arn")}
+	if taskRole != "" {
+		roles = append(roles, cloudformation.GetAtt(taskRole, "Arn"))
+	}
+
+	role := fmt.Sprintf("%sScheduleRole", normalizeResourceName(service.Name))
+	template.Resources[role] = &iam.Role{
+		AssumeRolePolicyDocument: eventsAssumeRolePolicyDocument,
+		Path:                     "/",
+		Policies: []iam.Role_Policy{
+			{
+				PolicyDocument: &PolicyDocument{
+					Statement: []PolicyStatement{
+						{
+							Effect:   "Allow",
+							Action:   []string{actionRunTask},
+							Resource: []string{cloudformation.Ref(taskDefinition)},
+						},
+						{
+							Effect:   "Allow",
+							Action:   []string{actionPassRole},
+							Resource: roles,
+						},
+					},
+				},
+				PolicyName: "service-schedule",
+			},
+		},
+		Tags: serviceTags(project, service),
+	}
+
+	launchType, platformVersion, assignPublicIP := getLaunchParameters(service, resources)
+	template.Resources[scheduleRuleResourceName(service.Name)] = &events.Rule{
+		AWSCloudFormationDependsOn: b.serviceDependencies(project, service, resources),
+		Description:                fmt.Sprintf("Run %s service from %s application", service.Name, project.Name),
+		ScheduleExpression:         expression,
+		State:                      "ENABLED",
+		Targets: []events.Rule_Target{
+			{
+				Arn: resources.cluster.ARN(),
+				EcsParameters: &events.Rule_EcsParameters{
+					LaunchType: launchType,
+					NetworkConfiguration: &events.Rule_NetworkConfiguration{
+						AwsVpcConfiguration: &events.Rule_AwsVpcConfiguration{
+							AssignPublicIp: assignPublicIP,
+							SecurityGroups: resources.serviceSecurityGroups(service),
+							Subnets:        resources.subnetsIDs(),
+						},
+					},
+					PlatformVersion:   platformVersion,
+					TaskCount:         getDesiredCount(service),
+					TaskDefinitionArn: cloudformation.Ref(taskDefinition),
+				},
+				Id:      normalizeResourceName(service.Name),
+				RoleArn: cloudformation.GetAtt(role, "Arn"),
+			},
+		},
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/events"
+	"github.com/awslabs/goformation/v4/cloudformation/iam"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestScheduledTask(t *testing.T) {
+	template := convertYaml(t, `
+services:
+  worker:
+    image: worker
+    x-aws-schedule: "cron(0 3 * * ? *)"
+    deploy:
+      replicas: 2
+  api:
+    image: api
+    depends_on:
+      - worker
+`, nil, useDefaultVPC)
+	assert.Check(t, template.Resources["WorkerService"] == nil)
+	assert.Check(t, template.Resources["WorkerServiceDiscoveryEntry"] == nil)
+
+	rule := template.Resources["WorkerScheduleRule"].(*events.Rule)
+	assert.Equal(t, rule.ScheduleExpression, "cron(0 3 * * ? *)")
+	target := rule.Targets[0]
+	assert.Equal(t, target.RoleArn, cloudformation.GetAtt("WorkerScheduleRole", "Arn"))
+	assert.Equal(t, target.EcsParameters.TaskDefinitionArn, cloudformation.Ref("WorkerTaskDefinition"))
+	assert.Equal(t, target.EcsParameters.TaskCount, 2)
+	assert.Equal(t, target.EcsParameters.LaunchType, "FARGATE")
+	assert.DeepEqual(t, target.EcsParameters.NetworkConfiguration.AwsVpcConfiguration.Subnets, []string{"subnet1", "subnet2"})
+
+	role := template.Resources["WorkerScheduleRole"].(*iam.Role)
+	assert.DeepEqual(t, role.AssumeRolePolicyDocument, eventsAssumeRolePolicyDocument)
+
+	api := template.Resources["ApiService"].(*ecs.Service)
+	assert.DeepEqual(t, api.AWSCloudFormationDependsOn, []string{"WorkerScheduleRule"})
+}
+
+func TestScheduledTaskInvalidExpression(t *testing.T) {
+	convertYaml(t, `
+services:
+  worker:
+    image: worker
+    x-aws-schedule: "0 3 * * *"
+`, errors.New("service worker: x-aws-schedule must be a cron() or rate() schedule expression"), useDefaultVPC)
+}
+
+func TestScheduledTaskWithPorts(t *testing.T) {
+	convertYaml(t, `
+services:
+  worker:
+    image: worker
+    x-aws-schedule: "rate(1 hour)"
+    ports:
+      - 8080:8080
+`, errors.New("service worker: x-aws-schedule can't be used with ports"), useDefaultVPC)
+}
+
+func TestListScheduledServices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	m.EXPECT().ListStackResources(gomock.Any(), "test").Return(stackResources{
+		{LogicalID: "ApiTaskDefinition", Type: "AWS::ECS::TaskDefinition", ARN: "arn:aws:ecs:us-east-1:012345678910:task-definition/test-api:1"},
+		{LogicalID: "ApiService", Type: "AWS::ECS::Service", ARN: "arn:aws:ecs:us-east-1:012345678910:service/test-api"},
+		{LogicalID: "WorkerTaskDefinition", Type: "AWS::ECS::TaskDefinition", ARN: "arn:aws:ecs:us-east-1:012345678910:task-definition/test-worker:3"},
+		{LogicalID: "WorkerScheduleRule", Type: "AWS::Events::Rule", ARN: "test-WorkerScheduleRule-1234"},
+	}, nil)
+
+	backend := &ecsAPIService{aws: m}
+	services, err := backend.listScheduledServices(context.TODO(), "test")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, services, []string{"worker"})
+}
@@ -19,6 +19,7 @@ package ecs
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -34,8 +36,12 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/aws/aws-sdk-go/service/codedeploy"
+	"github.com/aws/aws-sdk-go/service/codedeploy/codedeployiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
 	"github.com/aws/aws-sdk-go/service/efs"
@@ -51,6 +57,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	clitypes "github.com/docker/cli/cli/config/types"
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-uuid"
@@ -64,6 +71,7 @@ import (
 type sdk struct {
 	ECS      ecsiface.ECSAPI
 	EC2      ec2iface.EC2API
+	ECR      ecriface.ECRAPI
 	EFS      efsiface.EFSAPI
 	ELB      elbv2iface.ELBV2API
 	CW       cloudwatchlogsiface.CloudWatchLogsAPI
@@ -73,6 +81,7 @@ type sdk struct {
 	SSM      ssmiface.SSMAPI
 	AG       autoscalingiface.AutoScalingAPI
 	S3       s3iface.S3API
+	CD       codedeployiface.CodeDeployAPI
 	uploader *s3manager.Uploader
 }
 
@@ -89,6 +98,7 @@ func newSDK(sess *session.Session) sdk {
 	return sdk{
 		ECS:      ecs.New(sess),
 		EC2:      ec2.New(sess),
+		ECR:      ecr.New(sess),
 		EFS:      efs.New(sess),
 		ELB:      elbv2.New(sess),
 		CW:       cloudwatchlogs.New(sess),
@@ -98,6 +108,7 @@ func newSDK(sess *session.Session) sdk {
 		SSM:      ssm.New(sess),
 		AG:       autoscaling.New(sess),
 		S3:       s3.New(sess),
+		CD:       codedeploy.New(sess),
 		uploader: s3manager.NewUploader(sess),
 	}
 }
@@ -213,6 +224,27 @@ func (s sdk) GetSubNets(ctx context.Context, vpcID string) ([]awsResource, error
 }
 
 func (s sdk) IsPublicSubnet(ctx context.Context, subNetID string) (bool, error) {
+	tables, err := s.subnetRouteTables(ctx, subNetID)
+	if err != nil {
+		return false, err
+	}
+	for _, routeTable := range tables {
+		for _, route := range routeTable.Routes {
+			if aws.StringValue(route.State) != "active" {
+				continue
+			}
+			if strings.HasPrefix(aws.StringValue(route.GatewayId), "igw-") {
+				// Connected to an internet Gateway
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// subnetRouteTables returns the route tables explicitly associated with subnet, or the VPC main route table a subnet
+// without explicit association implicitly uses
+func (s sdk) subnetRouteTables(ctx context.Context, subNetID string) ([]*ec2.RouteTable, error) {
 	tables, err := s.EC2.DescribeRouteTablesWithContext(ctx, &ec2.DescribeRouteTablesInput{
 		Filters: []*ec2.Filter{
 			{
@@ -222,20 +254,52 @@ func (s sdk) IsPublicSubnet(ctx context.Context, subNetID string) (bool, error)
 		},
 	})
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if len(tables.RouteTables) > 0 {
+		return tables.RouteTables, nil
+	}
+
+	// https://docs.aws.amazon.com/cli/latest/reference/ec2/describe-route-tables.html
+	subnets, err := s.EC2.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{
+		SubnetIds: []*string{aws.String(subNetID)},
+	})
+	if err != nil {
+		return nil, err
 	}
-	if len(tables.RouteTables) == 0 {
-		// If a subnet is not explicitly associated with any route table, it is implicitly associated with the main route table.
-		// https://docs.aws.amazon.com/cli/latest/reference/ec2/describe-route-tables.html
-		return true, nil
+	if len(subnets.Subnets) == 0 {
+		return nil, errors.Wrapf(api.ErrNotFound, "subnet %q does not exist", subNetID)
 	}
-	for _, routeTable := range tables.RouteTables {
+	tables, err = s.EC2.DescribeRouteTablesWithContext(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{subnets.Subnets[0].VpcId},
+			},
+			{
+				Name:   aws.String("association.main"),
+				Values: []*string{aws.String("true")},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tables.RouteTables, nil
+}
+
+func (s sdk) HasNATRoute(ctx context.Context, subNetID string) (bool, error) {
+	tables, err := s.subnetRouteTables(ctx, subNetID)
+	if err != nil {
+		return false, err
+	}
+	for _, routeTable := range tables {
 		for _, route := range routeTable.Routes {
-			if aws.StringValue(route.State) != "active" {
+			if aws.StringValue(route.State) != "active" || aws.StringValue(route.DestinationCidrBlock) != "0.0.0.0/0" {
 				continue
 			}
-			if strings.HasPrefix(aws.StringValue(route.GatewayId), "igw-") {
-				// Connected to an internet Gateway
+			// NAT gateway, NAT instance or a transit gateway routing to a shared egress VPC
+			if route.NatGatewayId != nil || route.InstanceId != nil || route.TransitGatewayId != nil {
 				return true, nil
 			}
 		}
@@ -406,7 +470,7 @@ func (s sdk) UpdateStack(ctx context.Context, changeset string) error {
 		return err
 	}
 
-	if strings.HasPrefix(aws.StringValue(desc.StatusReason), "The submitted information didn't contain changes.") {
+	if strings.HasPrefix(aws.StringValue(desc.StatusReason), noChangesReason) {
 		return nil
 	}
 
@@ -416,6 +480,55 @@ func (s sdk) UpdateStack(ctx context.Context, changeset string) error {
 	return err
 }
 
+// noChangesReason is reported by CloudFormation when a change set doesn't contain any change
+const noChangesReason = "The submitted information didn't contain changes."
+
+// stackChange describes a resource-level change from a CloudFormation change set
+type stackChange struct {
+	Action      string
+	LogicalID   string
+	Type        string
+	Replacement string
+}
+
+func (s sdk) DescribeChangeSet(ctx context.Context, changeset string) ([]stackChange, error) {
+	var changes []stackChange
+	var token *string
+	for {
+		desc, err := s.CF.DescribeChangeSetWithContext(ctx, &cloudformation.DescribeChangeSetInput{
+			ChangeSetName: aws.String(changeset),
+			NextToken:     token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range desc.Changes {
+			r := change.ResourceChange
+			if r == nil {
+				continue
+			}
+			changes = append(changes, stackChange{
+				Action:      aws.StringValue(r.Action),
+				LogicalID:   aws.StringValue(r.LogicalResourceId),
+				Type:        aws.StringValue(r.ResourceType),
+				Replacement: aws.StringValue(r.Replacement),
+			})
+		}
+		if desc.NextToken == nil {
+			return changes, nil
+		}
+		token = desc.NextToken
+	}
+}
+
+func (s sdk) DeleteChangeSet(ctx context.Context, changeset string) error {
+	logrus.Debug("Delete CloudFormation Changeset")
+	_, err := s.CF.DeleteChangeSetWithContext(ctx, &cloudformation.DeleteChangeSetInput{
+		ChangeSetName: aws.String(changeset),
+	})
+	return err
+}
+
 const (
 	stackCreate = iota
 	stackUpdate
@@ -593,6 +706,7 @@ func (s sdk) GetServiceTasks(ctx context.Context, cluster string, service string
 			Cluster:       aws.String(cluster),
 			ServiceName:   aws.String(service),
 			DesiredStatus: aws.String(state),
+			NextToken:     token,
 		})
 		if err != nil {
 			return nil, err
@@ -607,7 +721,7 @@ func (s sdk) GetServiceTasks(ctx context.Context, cluster string, service string
 			}
 			tasks = append(tasks, taskDescriptions.Tasks...)
 		}
-		if token == response.NextToken {
+		if response.NextToken == nil {
 			return tasks, nil
 		}
 		token = response.NextToken
@@ -625,15 +739,103 @@ func (s sdk) GetTaskStoppedReason(ctx context.Context, cluster string, taskArn s
 	if len(taskDescriptions.Tasks) == 0 {
 		return "", nil
 	}
-	task := taskDescriptions.Tasks[0]
-	return fmt.Sprintf(
+	return taskStoppedReason(taskDescriptions.Tasks[0]), nil
+}
+
+// taskStoppedReason describes why a task stopped, including reason or exit code of failed containers
+func taskStoppedReason(task *ecs.Task) string {
+	reason := fmt.Sprintf(
 		"%s: %s",
 		aws.StringValue(task.StopCode),
-		aws.StringValue(task.StoppedReason)), nil
+		aws.StringValue(task.StoppedReason))
+	for _, container := range task.Containers {
+		switch {
+		case container.Reason != nil:
+			reason = fmt.Sprintf("%s, %s: %s", reason, aws.StringValue(container.Name), aws.StringValue(container.Reason))
+		case aws.Int64Value(container.ExitCode) != 0:
+			reason = fmt.Sprintf("%s, %s exited with code %d", reason, aws.StringValue(container.Name), aws.Int64Value(container.ExitCode))
+		}
+	}
+	return reason
+}
 
+// serviceEvents holds the latest events of an ECS service, along with the compose service it runs
+type serviceEvents struct {
+	Service string
+	Events  []*ecs.ServiceEvent
+}
+
+func (s sdk) CreateDeployment(ctx context.Context, application string, group string, appSpec string) (string, error) {
+	logrus.Debug("Create CodeDeploy deployment for deployment group ", group)
+	response, err := s.CD.CreateDeploymentWithContext(ctx, &codedeploy.CreateDeploymentInput{
+		ApplicationName:     aws.String(application),
+		DeploymentGroupName: aws.String(group),
+		Revision: &codedeploy.RevisionLocation{
+			RevisionType: aws.String(codedeploy.RevisionLocationTypeAppSpecContent),
+			AppSpecContent: &codedeploy.AppSpecContent{
+				Content: aws.String(appSpec),
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(response.DeploymentId), nil
+}
+
+func (s sdk) GetDeploymentStatus(ctx context.Context, id string) (string, string, error) {
+	response, err := s.CD.GetDeploymentWithContext(ctx, &codedeploy.GetDeploymentInput{
+		DeploymentId: aws.String(id),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	info := response.DeploymentInfo
+	var message string
+	if info.ErrorInformation != nil {
+		message = aws.StringValue(info.ErrorInformation.Message)
+	}
+	return aws.StringValue(info.Status), message, nil
+}
+
+func (s sdk) DescribeServiceEvents(ctx context.Context, cluster string, serviceArns []string) (map[string]serviceEvents, error) {
+	events := map[string]serviceEvents{}
+	for i := 0; i < len(serviceArns); i += 10 {
+		end := i + 10
+		if end > len(serviceArns) {
+			end = len(serviceArns)
+		}
+		services, err := s.ECS.DescribeServicesWithContext(ctx, &ecs.DescribeServicesInput{
+			Cluster:  aws.String(cluster),
+			Services: aws.StringSlice(serviceArns[i:end]),
+			Include:  aws.StringSlice([]string{"TAGS"}),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range services.Services {
+			name := aws.StringValue(service.ServiceName)
+			for _, t := range service.Tags {
+				if aws.StringValue(t.Key) == api.ServiceLabel {
+					name = aws.StringValue(t.Value)
+				}
+			}
+			events[aws.StringValue(service.ServiceArn)] = serviceEvents{
+				Service: name,
+				Events:  service.Events,
+			}
+		}
+	}
+	return events, nil
 }
 
 func (s sdk) DescribeStackEvents(ctx context.Context, stackID string) ([]*cloudformation.StackEvent, error) {
+	return s.DescribeStackEventsSince(ctx, stackID, "")
+}
+
+// DescribeStackEventsSince lists stack events, most recent first, which occurred after the lastEventID one. As events
+// are returned in reverse chronological order, pagination stops as soon as this event is reached
+func (s sdk) DescribeStackEventsSince(ctx context.Context, stackID string, lastEventID string) ([]*cloudformation.StackEvent, error) {
 	// Fixme implement Paginator on Events and return as a chan(events)
 	events := []*cloudformation.StackEvent{}
 	var nextToken *string
@@ -646,7 +848,12 @@ func (s sdk) DescribeStackEvents(ctx context.Context, stackID string) ([]*cloudf
 			return nil, err
 		}
 
-		events = append(events, resp.StackEvents...)
+		for _, event := range resp.StackEvents {
+			if lastEventID != "" && aws.StringValue(event.EventId) == lastEventID {
+				return events, nil
+			}
+			events = append(events, event)
+		}
 		if resp.NextToken == nil {
 			return events, nil
 		}
@@ -669,6 +876,20 @@ func (s sdk) ListStackParameters(ctx context.Context, name string) (map[string]s
 	return parameters, nil
 }
 
+func (s sdk) ListStackOutputs(ctx context.Context, name string) (map[string]string, error) {
+	st, err := s.CF.DescribeStacksWithContext(ctx, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	outputs := map[string]string{}
+	for _, output := range st.Stacks[0].Outputs {
+		outputs[aws.StringValue(output.OutputKey)] = aws.StringValue(output.OutputValue)
+	}
+	return outputs, nil
+}
+
 type stackResource struct {
 	LogicalID string
 	Type      string
@@ -693,6 +914,26 @@ func (resources stackResources) apply(awsType string, fn stackResourceFn) error
 	return errs.ErrorOrNil()
 }
 
+// ecsServices returns the ECS cluster and the services already created by the stack, indexed by ARN to their logical ID
+func (resources stackResources) ecsServices() (string, []string, map[string]string) {
+	var cluster string
+	svcArns := []string{}
+	svcNames := map[string]string{}
+	for _, r := range resources {
+		switch r.Type {
+		case "AWS::ECS::Cluster":
+			cluster = r.ARN
+		case "AWS::ECS::Service":
+			if r.ARN == "" {
+				continue
+			}
+			svcArns = append(svcArns, r.ARN)
+			svcNames[r.ARN] = r.LogicalID
+		}
+	}
+	return cluster, svcArns, svcNames
+}
+
 func (s sdk) ListStackResources(ctx context.Context, name string) (stackResources, error) {
 	var token *string
 	var resources stackResources
@@ -719,6 +960,89 @@ func (s sdk) ListStackResources(ctx context.Context, name string) (stackResource
 	}
 }
 
+func (s sdk) GetStackTemplate(ctx context.Context, name string) ([]byte, error) {
+	response, err := s.CF.GetTemplateWithContext(ctx, &cloudformation.GetTemplateInput{
+		StackName:     aws.String(name),
+		TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(aws.StringValue(response.TemplateBody)), nil
+}
+
+// driftDetectionPollInterval is the delay between checks for a stack drift detection to complete
+const driftDetectionPollInterval = 5 * time.Second
+
+// resourceDrift describes a stack resource whose live configuration doesn't match the deployed template
+type resourceDrift struct {
+	LogicalID  string
+	Type       string
+	Status     string
+	Properties []string
+}
+
+func (s sdk) DetectStackDrift(ctx context.Context, name string) ([]resourceDrift, error) {
+	logrus.Debug("Detect CloudFormation stack drift")
+	detection, err := s.CF.DetectStackDriftWithContext(ctx, &cloudformation.DetectStackDriftInput{
+		StackName: aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		status, err := s.CF.DescribeStackDriftDetectionStatusWithContext(ctx, &cloudformation.DescribeStackDriftDetectionStatusInput{
+			StackDriftDetectionId: detection.StackDriftDetectionId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if aws.StringValue(status.DetectionStatus) == cloudformation.StackDriftDetectionStatusDetectionFailed {
+			return nil, fmt.Errorf("drift detection failed on stack %s: %s", name, aws.StringValue(status.DetectionStatusReason))
+		}
+		if aws.StringValue(status.DetectionStatus) != cloudformation.StackDriftDetectionStatusDetectionInProgress {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(driftDetectionPollInterval):
+		}
+	}
+
+	var drifts []resourceDrift
+	var token *string
+	for {
+		response, err := s.CF.DescribeStackResourceDriftsWithContext(ctx, &cloudformation.DescribeStackResourceDriftsInput{
+			StackName: aws.String(name),
+			StackResourceDriftStatusFilters: aws.StringSlice([]string{
+				cloudformation.StackResourceDriftStatusModified,
+				cloudformation.StackResourceDriftStatusDeleted,
+			}),
+			NextToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range response.StackResourceDrifts {
+			var properties []string
+			for _, p := range d.PropertyDifferences {
+				properties = append(properties, aws.StringValue(p.PropertyPath))
+			}
+			drifts = append(drifts, resourceDrift{
+				LogicalID:  aws.StringValue(d.LogicalResourceId),
+				Type:       aws.StringValue(d.ResourceType),
+				Status:     aws.StringValue(d.StackResourceDriftStatus),
+				Properties: properties,
+			})
+		}
+		token = response.NextToken
+		if token == nil {
+			return drifts, nil
+		}
+	}
+}
+
 func (s sdk) DeleteStack(ctx context.Context, name string) error {
 	logrus.Debug("Delete CloudFormation stack")
 	_, err := s.CF.DeleteStackWithContext(ctx, &cloudformation.DeleteStackInput{
@@ -888,17 +1212,22 @@ func (s sdk) DescribeService(ctx context.Context, cluster string, arn string) (a
 	}, nil
 }
 
-func (s sdk) DescribeServiceTasks(ctx context.Context, cluster string, project string, service string) ([]api.ContainerSummary, error) {
+func (s sdk) DescribeServiceTasks(ctx context.Context, cluster string, project string, service string, stopped bool) ([]api.ContainerSummary, error) {
 	var summary []api.ContainerSummary
 	familly := fmt.Sprintf("%s-%s", project, service)
+	desiredStatus := ecs.DesiredStatusRunning
+	if stopped {
+		desiredStatus = ecs.DesiredStatusStopped
+	}
 	var token *string
 	for {
 		list, err := s.ECS.ListTasks(&ecs.ListTasksInput{
-			Cluster:    aws.String(cluster),
-			Family:     aws.String(familly),
-			LaunchType: nil,
-			MaxResults: nil,
-			NextToken:  token,
+			Cluster:       aws.String(cluster),
+			DesiredStatus: aws.String(desiredStatus),
+			Family:        aws.String(familly),
+			LaunchType:    nil,
+			MaxResults:    nil,
+			NextToken:     token,
 		})
 		if err != nil {
 			return nil, err
@@ -917,30 +1246,15 @@ func (s sdk) DescribeServiceTasks(ctx context.Context, cluster string, project s
 		}
 
 		for _, t := range tasks.Tasks {
-			var project string
-			var service string
-			for _, tag := range t.Tags {
-				switch aws.StringValue(tag.Key) {
-				case api.ProjectLabel:
-					project = aws.StringValue(tag.Value)
-				case api.ServiceLabel:
-					service = aws.StringValue(tag.Value)
-				}
-			}
-
-			id, err := arn.Parse(aws.StringValue(t.TaskArn))
+			container, err := taskSummary(t, project, service)
 			if err != nil {
 				return nil, err
 			}
-
-			summary = append(summary, api.ContainerSummary{
-				ID:      id.String(),
-				Name:    id.Resource,
-				Project: project,
-				Service: service,
-				//nolint:staticcheck // Preserving for compatibility
-				State: strings.Title(strings.ToLower(aws.StringValue(t.LastStatus))),
-			})
+			if t.StoppedAt != nil {
+				// container summary has no field for the stop reason, which is reported aside to keep state parseable
+				logrus.Warnf("%s %s stopped: %s", container.Service, container.Name, taskStoppedReason(t))
+			}
+			summary = append(summary, container)
 		}
 
 		if list.NextToken == token {
@@ -952,6 +1266,43 @@ func (s sdk) DescribeServiceTasks(ctx context.Context, cluster string, project s
 	return summary, nil
 }
 
+// taskSummary converts an ECS task into a container summary. Stopped tasks are reported as exited, with the exit code
+// of service container
+func taskSummary(t *ecs.Task, project string, service string) (api.ContainerSummary, error) {
+	// scheduled tasks are run by EventBridge without tags, so we fall back to the requested project and service
+	for _, tag := range t.Tags {
+		switch aws.StringValue(tag.Key) {
+		case api.ProjectLabel:
+			project = aws.StringValue(tag.Value)
+		case api.ServiceLabel:
+			service = aws.StringValue(tag.Value)
+		}
+	}
+
+	id, err := arn.Parse(aws.StringValue(t.TaskArn))
+	if err != nil {
+		return api.ContainerSummary{}, err
+	}
+
+	summary := api.ContainerSummary{
+		ID:      id.String(),
+		Name:    id.Resource,
+		Project: project,
+		Service: service,
+		//nolint:staticcheck // Preserving for compatibility
+		State: strings.Title(strings.ToLower(aws.StringValue(t.LastStatus))),
+	}
+	if t.StoppedAt != nil {
+		summary.State = "exited"
+		for _, c := range t.Containers {
+			if aws.StringValue(c.Name) == service {
+				summary.ExitCode = int(aws.Int64Value(c.ExitCode))
+			}
+		}
+	}
+	return summary, nil
+}
+
 func (s sdk) getURLWithPortMapping(ctx context.Context, targetGroupArns []string) ([]api.PortPublisher, error) {
 	if len(targetGroupArns) == 0 {
 		return nil, nil
@@ -986,6 +1337,10 @@ func (s sdk) getURLWithPortMapping(ctx context.Context, targetGroupArns []string
 		}
 		return nil
 	}
+	published, err := s.getListenerPorts(ctx, lbarns)
+	if err != nil {
+		return nil, err
+	}
 	loadBalancers := []api.PortPublisher{}
 	for _, tg := range groups.TargetGroups {
 		for _, lbarn := range tg.LoadBalancerArns {
@@ -993,10 +1348,14 @@ func (s sdk) getURLWithPortMapping(ctx context.Context, targetGroupArns []string
 			if lb == nil {
 				continue
 			}
+			port, ok := published[aws.StringValue(tg.TargetGroupArn)]
+			if !ok {
+				port = aws.Int64Value(tg.Port)
+			}
 			loadBalancers = append(loadBalancers, api.PortPublisher{
-				URL:           fmt.Sprintf("%s:%d", aws.StringValue(lb.DNSName), aws.Int64Value(tg.Port)),
+				URL:           fmt.Sprintf("%s:%d", aws.StringValue(lb.DNSName), port),
 				TargetPort:    int(aws.Int64Value(tg.Port)),
-				PublishedPort: int(aws.Int64Value(tg.Port)),
+				PublishedPort: int(port),
 				Protocol:      strings.ToLower(aws.StringValue(tg.Protocol)),
 			})
 
@@ -1005,6 +1364,47 @@ func (s sdk) getURLWithPortMapping(ctx context.Context, targetGroupArns []string
 	return loadBalancers, nil
 }
 
+// getListenerPorts maps target groups to the port of the load balancer listener forwarding requests to them, either
+// as default action or by a listener rule
+func (s sdk) getListenerPorts(ctx context.Context, lbarns []*string) (map[string]int64, error) {
+	ports := map[string]int64{}
+	forwarded := func(actions []*elbv2.Action, port int64) {
+		for _, action := range actions {
+			if arn := aws.StringValue(action.TargetGroupArn); arn != "" {
+				ports[arn] = port
+			}
+			if action.ForwardConfig == nil {
+				continue
+			}
+			for _, tg := range action.ForwardConfig.TargetGroups {
+				ports[aws.StringValue(tg.TargetGroupArn)] = port
+			}
+		}
+	}
+	for _, lbarn := range lbarns {
+		listeners, err := s.ELB.DescribeListenersWithContext(ctx, &elbv2.DescribeListenersInput{
+			LoadBalancerArn: lbarn,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, listener := range listeners.Listeners {
+			port := aws.Int64Value(listener.Port)
+			forwarded(listener.DefaultActions, port)
+			rules, err := s.ELB.DescribeRulesWithContext(ctx, &elbv2.DescribeRulesInput{
+				ListenerArn: listener.ListenerArn,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, rule := range rules.Rules {
+				forwarded(rule.Actions, port)
+			}
+		}
+	}
+	return ports, nil
+}
+
 func (s sdk) ListTasks(ctx context.Context, cluster string, family string) ([]string, error) {
 	var token *string
 	var arns []string
@@ -1250,3 +1650,79 @@ func (s sdk) DeleteFileSystem(ctx context.Context, id string) error {
 	})
 	return err
 }
+
+func (s sdk) ResolveRepository(ctx context.Context, name string, tags map[string]string) (string, error) {
+	repositories, err := s.ECR.DescribeRepositoriesWithContext(ctx, &ecr.DescribeRepositoriesInput{
+		RepositoryNames: aws.StringSlice([]string{name}),
+	})
+	if err == nil && len(repositories.Repositories) > 0 {
+		return aws.StringValue(repositories.Repositories[0].RepositoryUri), nil
+	}
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != ecr.ErrCodeRepositoryNotFoundException {
+			return "", err
+		}
+	}
+
+	logrus.Debug("Create ECR repository " + name)
+	var ecrTags []*ecr.Tag
+	for k, v := range tags {
+		ecrTags = append(ecrTags, &ecr.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(v),
+		})
+	}
+	repository, err := s.ECR.CreateRepositoryWithContext(ctx, &ecr.CreateRepositoryInput{
+		RepositoryName: aws.String(name),
+		ImageScanningConfiguration: &ecr.ImageScanningConfiguration{
+			ScanOnPush: aws.Bool(true),
+		},
+		Tags: ecrTags,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(repository.Repository.RepositoryUri), nil
+}
+
+func (s sdk) GetRegistryCredentials(ctx context.Context) (clitypes.AuthConfig, error) {
+	token, err := s.ECR.GetAuthorizationTokenWithContext(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return clitypes.AuthConfig{}, err
+	}
+	if len(token.AuthorizationData) == 0 {
+		return clitypes.AuthConfig{}, fmt.Errorf("no authorization token returned by ECR")
+	}
+	data := token.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(aws.StringValue(data.AuthorizationToken))
+	if err != nil {
+		return clitypes.AuthConfig{}, err
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return clitypes.AuthConfig{}, fmt.Errorf("invalid authorization token returned by ECR")
+	}
+	return clitypes.AuthConfig{
+		Username:      parts[0],
+		Password:      parts[1],
+		ServerAddress: strings.TrimPrefix(aws.StringValue(data.ProxyEndpoint), "https://"),
+	}, nil
+}
+
+func (s sdk) GetImageDigest(ctx context.Context, repository string, tag string) (string, error) {
+	images, err := s.ECR.DescribeImagesWithContext(ctx, &ecr.DescribeImagesInput{
+		RepositoryName: aws.String(repository),
+		ImageIds: []*ecr.ImageIdentifier{
+			{
+				ImageTag: aws.String(tag),
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(images.ImageDetails) == 0 {
+		return "", fmt.Errorf("image %s:%s not found", repository, tag)
+	}
+	return aws.StringValue(images.ImageDetails[0].ImageDigest), nil
+}
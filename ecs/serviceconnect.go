@@ -0,0 +1,137 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/awslabs/goformation/v4/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/compose-spec/compose-go/types"
+)
+
+// useServiceConnect checks project opted-in for ECS Service Connect, as an alternative to Cloud Map DNS records and
+// search domain init container for service discovery
+func useServiceConnect(project *types.Project) bool {
+	enabled, ok := project.Extensions[extensionServiceConnect].(bool)
+	return ok && enabled
+}
+
+// maxPortNameLength is the maximum length of a port mapping name, also used as Service Connect discovery name
+const maxPortNameLength = 64
+
+// invalidPortNameChars matches characters not allowed in a port mapping name, which compose allows in service names
+var invalidPortNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// serviceConnectPortName names a port mapping after service and target port, restricted to lowercase letters,
+// numbers, underscores and hyphens
+func serviceConnectPortName(service string, port types.ServicePortConfig) string {
+	suffix := fmt.Sprintf("-%d", port.Target)
+	name := strings.TrimLeft(invalidPortNameChars.ReplaceAllString(strings.ToLower(service), "-"), "-_")
+	if len(name) > maxPortNameLength-len(suffix) {
+		name = name[:maxPortNameLength-len(suffix)]
+	}
+	return name + suffix
+}
+
+// serviceConnectPorts returns the ports service can be reached on through Service Connect, which only supports TCP.
+// Ports publishing the same target are only returned once.
+func serviceConnectPorts(service types.ServiceConfig) []types.ServicePortConfig {
+	var ports []types.ServicePortConfig
+	targets := map[uint32]bool{}
+	for _, port := range service.Ports {
+		if (port.Protocol == "" || port.Protocol == "tcp") && !targets[port.Target] {
+			targets[port.Target] = true
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// serviceConnectDefaults sets cluster default Service Connect namespace to the project Cloud Map namespace
+func serviceConnectDefaults() map[string]interface{} {
+	return map[string]interface{}{
+		extraProperties: map[string]interface{}{
+			"ServiceConnectDefaults": map[string]interface{}{
+				"Namespace": cloudformation.GetAtt("CloudMap", "Arn"),
+			},
+		},
+	}
+}
+
+// serviceConnectPortMappings names the service container port mappings, so they can be referred to by Service Connect
+// configuration. container is the index of service container within task definition.
+func serviceConnectPortMappings(service types.ServiceConfig, container int) map[string]interface{} {
+	var mappings []interface{}
+	named := map[uint32]bool{}
+	for _, port := range service.Ports {
+		mapping := map[string]interface{}{}
+		if (port.Protocol == "" || port.Protocol == "tcp") && !named[port.Target] {
+			named[port.Target] = true
+			mapping["Name"] = serviceConnectPortName(service.Name, port)
+		}
+		mappings = append(mappings, mapping)
+	}
+	containers := make([]interface{}, container+1)
+	for i := range containers {
+		containers[i] = map[string]interface{}{}
+	}
+	containers[container] = map[string]interface{}{
+		"PortMappings": mappings,
+	}
+	return map[string]interface{}{
+		extraProperties: map[string]interface{}{
+			"ContainerDefinitions": containers,
+		},
+	}
+}
+
+// serviceConnectConfiguration declares service as a Service Connect client, and as a server for the ports it
+// publishes, with compose service name as client alias
+func serviceConnectConfiguration(service types.ServiceConfig, logConfiguration *ecs.TaskDefinition_LogConfiguration) map[string]interface{} {
+	config := map[string]interface{}{
+		"Enabled":   true,
+		"Namespace": cloudformation.GetAtt("CloudMap", "Arn"),
+	}
+	var services []interface{}
+	for _, port := range serviceConnectPorts(service) {
+		name := serviceConnectPortName(service.Name, port)
+		services = append(services, map[string]interface{}{
+			"PortName":      name,
+			"DiscoveryName": name,
+			"ClientAliases": []interface{}{
+				map[string]interface{}{
+					"DnsName": service.Name,
+					"Port":    port.Target,
+				},
+			},
+		})
+	}
+	if len(services) > 0 {
+		config["Services"] = services
+	}
+	if logConfiguration != nil {
+		config["LogConfiguration"] = logConfiguration
+	}
+	return map[string]interface{}{
+		extraProperties: map[string]interface{}{
+			"ServiceConnectConfiguration": config,
+		},
+	}
+}
@@ -0,0 +1,198 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/sanathkr/go-yaml"
+	"gotest.tools/v3/assert"
+)
+
+func TestServiceConnect(t *testing.T) {
+	template := convertYaml(t, `
+x-aws-service_connect: true
+services:
+  api:
+    image: api
+    ports:
+      - 8080:8080
+      - 9000:9000/udp
+  worker:
+    image: worker
+`, nil, useDefaultVPC)
+	assert.Check(t, template.Resources["ApiServiceDiscoveryEntry"] == nil)
+	api := template.Resources["ApiService"].(*ecs.Service)
+	assert.Check(t, api.ServiceRegistries == nil)
+	for _, c := range template.Resources["ApiTaskDefinition"].(*ecs.TaskDefinition).ContainerDefinitions {
+		assert.Check(t, c.Name != "Api_ResolvConf_InitContainer")
+	}
+
+	bytes, err := marshall(template, "yaml")
+	assert.NilError(t, err)
+	var marshalled struct {
+		Resources map[string]struct {
+			Metadata   map[string]interface{} `yaml:"Metadata"`
+			Properties struct {
+				ServiceConnectDefaults      map[string]interface{} `yaml:"ServiceConnectDefaults"`
+				ServiceConnectConfiguration struct {
+					Enabled  bool `yaml:"Enabled"`
+					Services []struct {
+						PortName      string `yaml:"PortName"`
+						DiscoveryName string `yaml:"DiscoveryName"`
+						ClientAliases []struct {
+							DNSName string `yaml:"DnsName"`
+							Port    int    `yaml:"Port"`
+						} `yaml:"ClientAliases"`
+					} `yaml:"Services"`
+				} `yaml:"ServiceConnectConfiguration"`
+				ContainerDefinitions []struct {
+					Name         string `yaml:"Name"`
+					PortMappings []struct {
+						Name          string `yaml:"Name"`
+						ContainerPort int    `yaml:"ContainerPort"`
+					} `yaml:"PortMappings"`
+				} `yaml:"ContainerDefinitions"`
+			} `yaml:"Properties"`
+		} `yaml:"Resources"`
+	}
+	assert.NilError(t, yaml.Unmarshal(bytes, &marshalled))
+
+	cluster := marshalled.Resources["Cluster"]
+	assert.Check(t, cluster.Metadata == nil)
+	assert.Check(t, cluster.Properties.ServiceConnectDefaults["Namespace"] != nil)
+
+	config := marshalled.Resources["ApiService"].Properties.ServiceConnectConfiguration
+	assert.Check(t, config.Enabled)
+	assert.Equal(t, len(config.Services), 1)
+	assert.Equal(t, config.Services[0].PortName, "api-8080")
+	assert.Equal(t, config.Services[0].ClientAliases[0].DNSName, "api")
+	assert.Equal(t, config.Services[0].ClientAliases[0].Port, 8080)
+
+	worker := marshalled.Resources["WorkerService"].Properties.ServiceConnectConfiguration
+	assert.Check(t, worker.Enabled)
+	assert.Equal(t, len(worker.Services), 0)
+
+	containers := marshalled.Resources["ApiTaskDefinition"].Properties.ContainerDefinitions
+	assert.Equal(t, containers[0].Name, "api")
+	assert.Equal(t, containers[0].PortMappings[0].Name, "api-8080")
+	assert.Equal(t, containers[0].PortMappings[0].ContainerPort, 8080)
+	assert.Equal(t, containers[0].PortMappings[1].Name, "")
+}
+
+func TestServiceConnectWithSidecars(t *testing.T) {
+	template := convertYaml(t, `
+x-aws-service_connect: true
+services:
+  api:
+    image: api
+    ports:
+      - 9000:9000/udp
+      - 8080:8080
+    secrets:
+      - token
+    logging:
+      driver: fluentbit
+      options:
+        Name: cloudwatch
+        region: eu-west-3
+secrets:
+  token:
+    name: arn:aws:secretsmanager:eu-west-3:012345678910:secret:token
+    external: true
+`, nil, useDefaultVPC)
+	bytes, err := marshall(template, "yaml")
+	assert.NilError(t, err)
+	var marshalled struct {
+		Resources map[string]struct {
+			Metadata   map[string]interface{} `yaml:"Metadata"`
+			Properties struct {
+				ContainerDefinitions []struct {
+					Name         string `yaml:"Name"`
+					Image        string `yaml:"Image"`
+					Essential    bool   `yaml:"Essential"`
+					PortMappings []struct {
+						Name          string `yaml:"Name"`
+						ContainerPort int    `yaml:"ContainerPort"`
+						Protocol      string `yaml:"Protocol"`
+					} `yaml:"PortMappings"`
+				} `yaml:"ContainerDefinitions"`
+			} `yaml:"Properties"`
+		} `yaml:"Resources"`
+	}
+	assert.NilError(t, yaml.Unmarshal(bytes, &marshalled))
+
+	definition := marshalled.Resources["ApiTaskDefinition"]
+	assert.Check(t, definition.Metadata == nil)
+	containers := definition.Properties.ContainerDefinitions
+	var names []string
+	for _, c := range containers {
+		names = append(names, c.Name)
+	}
+	assert.DeepEqual(t, names, []string{"Api_Secrets_InitContainer", "api", "Api_LogRouter"})
+
+	// init container and log router are left unchanged by merged port mappings names
+	assert.Check(t, !containers[0].Essential)
+	assert.Equal(t, len(containers[0].PortMappings), 0)
+	assert.Equal(t, len(containers[2].PortMappings), 0)
+	assert.Check(t, containers[2].Image != "")
+
+	api := containers[1]
+	assert.Check(t, api.Essential)
+	assert.Equal(t, api.Image, "api")
+	assert.Equal(t, len(api.PortMappings), 2)
+	assert.Equal(t, api.PortMappings[0].Name, "")
+	assert.Equal(t, api.PortMappings[0].ContainerPort, 9000)
+	assert.Equal(t, api.PortMappings[0].Protocol, "udp")
+	assert.Equal(t, api.PortMappings[1].Name, "api-8080")
+	assert.Equal(t, api.PortMappings[1].ContainerPort, 8080)
+}
+
+func TestServiceConnectPortName(t *testing.T) {
+	port := types.ServicePortConfig{Target: 8080}
+	assert.Equal(t, serviceConnectPortName("api", port), "api-8080")
+	assert.Equal(t, serviceConnectPortName("My.Api", port), "my-api-8080")
+	assert.Equal(t, serviceConnectPortName(".api", port), "api-8080")
+	assert.Equal(t, serviceConnectPortName(strings.Repeat("a", 70), port), strings.Repeat("a", 59)+"-8080")
+}
+
+func TestServiceConnectPortsSameTarget(t *testing.T) {
+	service := types.ServiceConfig{
+		Name: "api",
+		Ports: []types.ServicePortConfig{
+			{Target: 80, Published: 80},
+			{Target: 80, Published: 8080},
+			{Target: 9000, Published: 9000},
+		},
+	}
+	ports := serviceConnectPorts(service)
+	assert.Equal(t, len(ports), 2)
+	assert.Equal(t, ports[0].Published, uint32(80))
+	assert.Equal(t, ports[1].Target, uint32(9000))
+
+	mappings := serviceConnectPortMappings(service, 0)[extraProperties].(map[string]interface{})["ContainerDefinitions"].([]interface{})
+	assert.DeepEqual(t, mappings[0], map[string]interface{}{
+		"PortMappings": []interface{}{
+			map[string]interface{}{"Name": "api-80"},
+			map[string]interface{}{},
+			map[string]interface{}{"Name": "api-9000"},
+		},
+	})
+}
@@ -0,0 +1,157 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	subnetsPublic  = "public"
+	subnetsPrivate = "private"
+)
+
+func hasSubnetsSelection(project *types.Project) bool {
+	for _, x := range []string{extensionTaskSubnets, extensionLoadBalancerSubnets, extensionPublicIP} {
+		if _, ok := project.Extensions[x]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func getSubnetsSelection(project *types.Project, extension string) (string, error) {
+	x, ok := project.Extensions[extension]
+	if !ok {
+		return "", nil
+	}
+	switch x {
+	case subnetsPublic, subnetsPrivate:
+		return x.(string), nil
+	}
+	return "", fmt.Errorf("%s must be either %q or %q", extension, subnetsPublic, subnetsPrivate)
+}
+
+func getPublicIP(project *types.Project) (bool, error) {
+	x, ok := project.Extensions[extensionPublicIP]
+	if !ok {
+		return true, nil
+	}
+	publicIP, ok := x.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s must be a boolean", extensionPublicIP)
+	}
+	return publicIP, nil
+}
+
+// selectSubnets sets the subnets tasks and load balancer are attached to. By default both use all subnets from the
+// VPC, which must have at least 2 public subnets for the load balancer. Tasks without a public IP require a NAT or VPC
+// endpoints to pull images, otherwise deployment would hang.
+func (b *ecsAPIService) selectSubnets(ctx context.Context, project *types.Project, subNets []awsResource, r *awsResources) error {
+	tasks, err := getSubnetsSelection(project, extensionTaskSubnets)
+	if err != nil {
+		return err
+	}
+	loadBalancer, err := getSubnetsSelection(project, extensionLoadBalancerSubnets)
+	if err != nil {
+		return err
+	}
+	publicIP, err := getPublicIP(project)
+	if err != nil {
+		return err
+	}
+
+	public, private, err := b.classifySubnets(ctx, subNets)
+	if err != nil {
+		return err
+	}
+
+	// an existing load balancer set by x-aws-loadbalancer is already attached to its own subnets
+	if r.loadBalancer == nil {
+		switch loadBalancer {
+		case subnetsPrivate:
+			if len(private) < 2 {
+				return fmt.Errorf("VPC %s should have at least 2 associated private subnets in different availability zones", r.vpc)
+			}
+			r.loadBalancerSubnets = private
+			r.internalLoadBalancer = true
+		default:
+			if len(public) < 2 {
+				return fmt.Errorf("VPC %s should have at least 2 associated public subnets in different availability zones", r.vpc)
+			}
+			if loadBalancer == subnetsPublic {
+				r.loadBalancerSubnets = public
+			}
+		}
+	}
+
+	r.subnets = subNets
+	switch tasks {
+	case subnetsPublic:
+		if len(public) == 0 {
+			return fmt.Errorf("VPC %s doesn't have public subnets to run tasks", r.vpc)
+		}
+		r.subnets = public
+	case subnetsPrivate:
+		if len(private) == 0 {
+			return fmt.Errorf("VPC %s doesn't have private subnets to run tasks", r.vpc)
+		}
+		r.subnets = private
+		publicIP = false
+	}
+
+	r.disablePublicIP = !publicIP
+	if r.disablePublicIP {
+		return b.checkNATRoutes(ctx, r.subnets)
+	}
+	return nil
+}
+
+func (b *ecsAPIService) classifySubnets(ctx context.Context, subNets []awsResource) ([]awsResource, []awsResource, error) {
+	var public, private []awsResource
+	for _, subNet := range subNets {
+		isPublic, err := b.aws.IsPublicSubnet(ctx, subNet.ID())
+		if err != nil {
+			return nil, nil, err
+		}
+		if isPublic {
+			public = append(public, subNet)
+		} else {
+			private = append(private, subNet)
+		}
+	}
+	return public, private, nil
+}
+
+// checkNATRoutes warns about subnets without a route to the internet through a NAT. Tasks running there can't pull
+// images from public registries, but still can use ECR and other AWS services through VPC endpoints.
+func (b *ecsAPIService) checkNATRoutes(ctx context.Context, subNets []awsResource) error {
+	for _, subNet := range subNets {
+		nat, err := b.aws.HasNATRoute(ctx, subNet.ID())
+		if err != nil {
+			return err
+		}
+		if !nat {
+			logrus.Warnf("subnet %s has no route to the internet through a NAT, tasks without a public IP will only be able to pull images through VPC endpoints", subNet.ID())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ecs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/awslabs/goformation/v4/cloudformation/ecs"
+	"github.com/awslabs/goformation/v4/cloudformation/elasticloadbalancingv2"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func useMixedVPC(m *MockAPIMockRecorder) {
+	m.GetDefaultVPC(gomock.Any()).Return("vpc-123", nil)
+	m.GetSubNets(gomock.Any(), "vpc-123").Return([]awsResource{
+		existingAWSResource{id: "public1"},
+		existingAWSResource{id: "public2"},
+		existingAWSResource{id: "private1"},
+		existingAWSResource{id: "private2"},
+	}, nil)
+	m.IsPublicSubnet(gomock.Any(), "public1").Return(true, nil)
+	m.IsPublicSubnet(gomock.Any(), "public2").Return(true, nil)
+	m.IsPublicSubnet(gomock.Any(), "private1").Return(false, nil)
+	m.IsPublicSubnet(gomock.Any(), "private2").Return(false, nil)
+}
+
+func TestPrivateTaskSubnets(t *testing.T) {
+	template := convertYaml(t, `
+x-aws-task_subnets: private
+x-aws-loadbalancer_subnets: public
+services:
+  test:
+    image: nginx
+    ports:
+      - 80:80
+`, nil, useMixedVPC, func(m *MockAPIMockRecorder) {
+		m.HasNATRoute(gomock.Any(), "private1").Return(true, nil)
+		m.HasNATRoute(gomock.Any(), "private2").Return(true, nil)
+	})
+	service := template.Resources["TestService"].(*ecs.Service)
+	vpc := service.NetworkConfiguration.AwsvpcConfiguration
+	assert.Equal(t, vpc.AssignPublicIp, "DISABLED")
+	assert.DeepEqual(t, vpc.Subnets, []string{"private1", "private2"})
+
+	lb := template.Resources["LoadBalancer"].(*elasticloadbalancingv2.LoadBalancer)
+	assert.Equal(t, lb.Scheme, "internet-facing")
+	assert.DeepEqual(t, lb.Subnets, []string{"public1", "public2"})
+}
+
+func TestInternalLoadBalancer(t *testing.T) {
+	template := convertYaml(t, `
+x-aws-task_subnets: private
+x-aws-loadbalancer_subnets: private
+services:
+  test:
+    image: nginx
+    ports:
+      - 80:80
+`, nil, func(m *MockAPIMockRecorder) {
+		m.GetDefaultVPC(gomock.Any()).Return("vpc-123", nil)
+		m.GetSubNets(gomock.Any(), "vpc-123").Return([]awsResource{
+			existingAWSResource{id: "private1"},
+			existingAWSResource{id: "private2"},
+		}, nil)
+		m.IsPublicSubnet(gomock.Any(), "private1").Return(false, nil)
+		m.IsPublicSubnet(gomock.Any(), "private2").Return(false, nil)
+		m.HasNATRoute(gomock.Any(), "private1").Return(true, nil)
+		m.HasNATRoute(gomock.Any(), "private2").Return(true, nil)
+	})
+	lb := template.Resources["LoadBalancer"].(*elasticloadbalancingv2.LoadBalancer)
+	assert.Equal(t, lb.Scheme, "internal")
+	assert.DeepEqual(t, lb.Subnets, []string{"private1", "private2"})
+}
+
+func TestPublicTaskSubnetsMissing(t *testing.T) {
+	convertYaml(t, `
+x-aws-task_subnets: public
+x-aws-loadbalancer_subnets: private
+services:
+  test:
+    image: nginx
+`, errors.New("VPC vpc-123 doesn't have public subnets to run tasks"), func(m *MockAPIMockRecorder) {
+		m.GetDefaultVPC(gomock.Any()).Return("vpc-123", nil)
+		m.GetSubNets(gomock.Any(), "vpc-123").Return([]awsResource{
+			existingAWSResource{id: "private1"},
+			existingAWSResource{id: "private2"},
+		}, nil)
+		m.IsPublicSubnet(gomock.Any(), "private1").Return(false, nil)
+		m.IsPublicSubnet(gomock.Any(), "private2").Return(false, nil)
+	})
+}
+
+func TestDisablePublicIPWithoutNAT(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+	convertYaml(t, `
+x-aws-public_ip: false
+x-aws-task_subnets: public
+services:
+  test:
+    image: nginx
+`, nil, useMixedVPC, func(m *MockAPIMockRecorder) {
+		m.HasNATRoute(gomock.Any(), "public1").Return(false, nil)
+		m.HasNATRoute(gomock.Any(), "public2").Return(true, nil)
+	})
+	var warnings []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			warnings = append(warnings, entry.Message)
+		}
+	}
+	assert.Check(t, is.Contains(warnings, "subnet public1 has no route to the internet through a NAT, tasks without a public IP will only be able to pull images through VPC endpoints"))
+}
+
+func TestInvalidSubnetsSelection(t *testing.T) {
+	convertYaml(t, `
+x-aws-task_subnets: isolated
+services:
+  test:
+    image: nginx
+`, errors.New(`x-aws-task_subnets must be either "public" or "private"`), func(m *MockAPIMockRecorder) {
+		m.GetDefaultVPC(gomock.Any()).Return("vpc-123", nil)
+		m.GetSubNets(gomock.Any(), "vpc-123").Return(nil, nil)
+	})
+}
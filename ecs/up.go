@@ -35,6 +35,12 @@ func (b *ecsAPIService) Up(ctx context.Context, project *types.Project, options
 	if err := checkUnsupportedUpOptions(ctx, options); err != nil {
 		return err
 	}
+	if isDryRun(project) {
+		return b.dryRun(ctx, project, os.Stdout)
+	}
+	if err := b.buildAndPushImages(ctx, project); err != nil {
+		return err
+	}
 	return progress.Run(ctx, func(ctx context.Context) error {
 		return b.up(ctx, project, options)
 	})
@@ -85,7 +91,9 @@ func (b *ecsAPIService) up(ctx context.Context, project *types.Project, options
 			return err
 		}
 	}
-	if options.Start.Attach == nil {
+	// blue/green services are updated by a CodeDeploy deployment, which can only start once stack has been updated
+	blueGreen := update && len(blueGreenServices(project)) > 0
+	if options.Start.Attach == nil && !blueGreen {
 		return nil
 	}
 	signalChan := make(chan os.Signal, 1)
@@ -97,7 +105,10 @@ func (b *ecsAPIService) up(ctx context.Context, project *types.Project, options
 	}()
 
 	err = b.WaitStackCompletion(ctx, project.Name, operation, previousEvents...)
-	return err
+	if err != nil || !blueGreen {
+		return err
+	}
+	return b.deployBlueGreenServices(ctx, project)
 }
 
 func checkUnsupportedUpOptions(ctx context.Context, o api.UpOptions) error {
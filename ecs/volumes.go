@@ -50,55 +50,78 @@ func (b *ecsAPIService) mountTargets(volume string, resources awsResources) []st
 	return refs
 }
 
-func (b *ecsAPIService) createAccessPoints(project *types.Project, r awsResources, template *cloudformation.Template) {
+// createAccessPoints declares an access point per volume, configured by volume driver_opts, as well as access points
+// dedicated to services which set x-aws-access_point on a volume mount
+func (b *ecsAPIService) createAccessPoints(project *types.Project, r awsResources, template *cloudformation.Template) error {
 	for name, volume := range project.Volumes {
-		n := fmt.Sprintf("%sAccessPoint", normalizeResourceName(name))
-
-		uid := volume.DriverOpts["uid"]
-		gid := volume.DriverOpts["gid"]
-		permissions := volume.DriverOpts["permissions"]
-		path := volume.DriverOpts["root_directory"]
-
-		ap := efs.AccessPoint{
-			AccessPointTags: []efs.AccessPoint_AccessPointTag{
-				{
-					Key:   api.ProjectLabel,
-					Value: project.Name,
-				},
-				{
-					Key:   api.VolumeLabel,
-					Value: name,
-				},
-				{
-					Key:   "Name",
-					Value: volume.Name,
-				},
-			},
-			FileSystemId: r.filesystems[name].ID(),
+		options, err := getVolumeAccessPointOptions(name, volume)
+		if err != nil {
+			return err
 		}
+		ap := accessPoint(project, name, volume.Name, r.filesystems[name], options)
+		template.Resources[fmt.Sprintf("%sAccessPoint", normalizeResourceName(name))] = ap
+	}
 
-		if uid != "" {
-			ap.PosixUser = &efs.AccessPoint_PosixUser{
-				Uid: uid,
-				Gid: gid,
-			}
+	for _, service := range project.Services {
+		accessPoints, err := serviceAccessPoints(service)
+		if err != nil {
+			return err
 		}
-		if path != "" {
-			root := efs.AccessPoint_RootDirectory{
-				Path: path,
-			}
-			ap.RootDirectory = &root
-			if uid != "" {
-				root.CreationInfo = &efs.AccessPoint_CreationInfo{
-					OwnerUid:    uid,
-					OwnerGid:    gid,
-					Permissions: permissions,
-				}
+		for name, options := range accessPoints {
+			volume, ok := project.Volumes[name]
+			if !ok {
+				return fmt.Errorf("service %s: volume %s is not declared", service.Name, name)
 			}
+			ap := accessPoint(project, name, volume.Name, r.filesystems[name], options)
+			ap.AccessPointTags = append(ap.AccessPointTags, efs.AccessPoint_AccessPointTag{
+				Key:   api.ServiceLabel,
+				Value: service.Name,
+			})
+			template.Resources[accessPointResourceName(service, name)] = ap
 		}
+	}
+	return nil
+}
+
+func accessPoint(project *types.Project, volume string, name string, filesystem awsResource, options accessPointOptions) *efs.AccessPoint {
+	ap := efs.AccessPoint{
+		AccessPointTags: []efs.AccessPoint_AccessPointTag{
+			{
+				Key:   api.ProjectLabel,
+				Value: project.Name,
+			},
+			{
+				Key:   api.VolumeLabel,
+				Value: volume,
+			},
+			{
+				Key:   "Name",
+				Value: name,
+			},
+		},
+		FileSystemId: filesystem.ID(),
+	}
 
-		template.Resources[n] = &ap
+	if options.UID != "" {
+		ap.PosixUser = &efs.AccessPoint_PosixUser{
+			Uid: options.UID,
+			Gid: options.GID,
+		}
+	}
+	if options.RootDirectory != "" {
+		root := efs.AccessPoint_RootDirectory{
+			Path: options.RootDirectory,
+		}
+		ap.RootDirectory = &root
+		if options.UID != "" {
+			root.CreationInfo = &efs.AccessPoint_CreationInfo{
+				OwnerUid:    options.UID,
+				OwnerGid:    options.GID,
+				Permissions: options.Permissions,
+			}
+		}
 	}
+	return &ap
 }
 
 // VolumeCreateOptions hold EFS filesystem creation options
@@ -26,8 +26,12 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/docker/compose/v2/pkg/progress"
 	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
 )
 
+// stoppedTasksPollInterval is the delay between checks for stopped tasks while a stack is being deployed
+const stoppedTasksPollInterval = 5 * time.Second
+
 func (b *ecsAPIService) WaitStackCompletion(ctx context.Context, name string, operation int, ignored ...string) error { //nolint:gocyclo
 	knownEvents := map[string]struct{}{}
 	for _, id := range ignored {
@@ -42,8 +46,13 @@ func (b *ecsAPIService) WaitStackCompletion(ctx context.Context, name string, op
 		return err
 	}
 
+	start := time.Now()
+	knownTasks := map[string]struct{}{}
+	var lastTasksCheck time.Time
+
 	ticker := time.NewTicker(1 * time.Second)
-	done := make(chan bool)
+	// buffered, so the goroutine doesn't leak if we stop waiting on error
+	done := make(chan bool, 1)
 	go func() {
 		b.aws.WaitStackComplete(ctx, stackID, operation) //nolint:errcheck
 		ticker.Stop()
@@ -102,6 +111,13 @@ func (b *ecsAPIService) WaitStackCompletion(ctx context.Context, name string, op
 			}
 			w.Event(progress.NewEvent(resource, progressStatus, fmt.Sprintf("%s %s", toCamelCase(status), reason)))
 		}
+		if operation != stackDelete && stackErr == nil && time.Since(lastTasksCheck) >= stoppedTasksPollInterval {
+			lastTasksCheck = time.Now()
+			// stopped tasks are only reported for diagnosis, failing to list them must not abort deployment
+			if err := b.reportStoppedTasks(ctx, name, start, knownTasks); err != nil {
+				logrus.Warnf("failed to report stopped tasks: %v", err)
+			}
+		}
 		if operation != stackCreate || stackErr != nil {
 			continue
 		}
@@ -118,6 +134,46 @@ func (b *ecsAPIService) WaitStackCompletion(ctx context.Context, name string, op
 	return stackErr
 }
 
+// reportStoppedTasks emits a progress event for tasks of the services being deployed which stopped since the
+// deployment started, so a failing rollout surfaces the container error before deployment circuit breaker triggers
+func (b *ecsAPIService) reportStoppedTasks(ctx context.Context, name string, since time.Time, knownTasks map[string]struct{}) error {
+	resources, err := b.aws.ListStackResources(ctx, name)
+	if err != nil {
+		return err
+	}
+	cluster, svcArns, svcNames := resources.ecsServices()
+	if len(svcArns) == 0 {
+		return nil
+	}
+	services, err := b.aws.GetServiceTaskDefinition(ctx, cluster, svcArns)
+	if err != nil {
+		return err
+	}
+	w := progress.ContextWriter(ctx)
+	for service, taskDef := range services {
+		tasks, err := b.aws.GetServiceTasks(ctx, cluster, service, true)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			arn := aws.StringValue(task.TaskArn)
+			if _, ok := knownTasks[arn]; ok {
+				continue
+			}
+			if aws.StringValue(task.TaskDefinitionArn) != taskDef || task.StoppedAt == nil || task.StoppedAt.Before(since) {
+				continue
+			}
+			knownTasks[arn] = struct{}{}
+			reason, err := b.aws.GetTaskStoppedReason(ctx, cluster, arn)
+			if err != nil {
+				return err
+			}
+			w.Event(progress.NewEvent(svcNames[service], progress.Working, fmt.Sprintf("TaskStopped %s", reason)))
+		}
+	}
+	return nil
+}
+
 func toCamelCase(status string) string {
 	return strcase.ToCamel(strings.ToLower(status))
 }
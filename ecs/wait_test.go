@@ -17,11 +17,79 @@
 package ecs
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/golang/mock/gomock"
 	"gotest.tools/v3/assert"
 )
 
 func TestStatusCamelCase(t *testing.T) {
 	assert.Equal(t, toCamelCase("CREATE_IN_PROGRESS"), "CreateInProgress")
 }
+
+type recordingWriter struct {
+	events []progress.Event
+}
+
+func (w *recordingWriter) Start(context.Context) error { return nil }
+
+func (w *recordingWriter) Stop() {}
+
+func (w *recordingWriter) Event(e progress.Event) {
+	w.events = append(w.events, e)
+}
+
+func (w *recordingWriter) TailMsgf(string, ...interface{}) {}
+
+func TestReportStoppedTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	since := time.Now()
+	before := since.Add(-time.Minute)
+	after := since.Add(time.Second)
+
+	m.EXPECT().ListStackResources(gomock.Any(), "test").Return(stackResources{
+		{LogicalID: "Cluster", Type: "AWS::ECS::Cluster", ARN: "cluster"},
+		{LogicalID: "FooService", Type: "AWS::ECS::Service", ARN: "foo"},
+		{LogicalID: "BarService", Type: "AWS::ECS::Service"},
+	}, nil).Times(2)
+	m.EXPECT().GetServiceTaskDefinition(gomock.Any(), "cluster", []string{"foo"}).Return(map[string]string{"foo": "foo:2"}, nil).Times(2)
+	m.EXPECT().GetServiceTasks(gomock.Any(), "cluster", "foo", true).Return([]*ecs.Task{
+		{TaskArn: aws.String("previous"), TaskDefinitionArn: aws.String("foo:1"), StoppedAt: &after},
+		{TaskArn: aws.String("old"), TaskDefinitionArn: aws.String("foo:2"), StoppedAt: &before},
+		{TaskArn: aws.String("stopping"), TaskDefinitionArn: aws.String("foo:2")},
+		{TaskArn: aws.String("failed"), TaskDefinitionArn: aws.String("foo:2"), StoppedAt: &after},
+	}, nil).Times(2)
+	m.EXPECT().GetTaskStoppedReason(gomock.Any(), "cluster", "failed").Return("EssentialContainerExited: Essential container in task exited", nil)
+
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+	backend := &ecsAPIService{aws: m}
+	known := map[string]struct{}{}
+	assert.NilError(t, backend.reportStoppedTasks(ctx, "test", since, known))
+	assert.NilError(t, backend.reportStoppedTasks(ctx, "test", since, known))
+	assert.Equal(t, len(w.events), 1)
+	assert.Equal(t, w.events[0].ID, "FooService")
+	assert.Equal(t, w.events[0].StatusText, "TaskStopped EssentialContainerExited: Essential container in task exited")
+}
+
+func TestWaitStackCompletionIgnoresStoppedTasksErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := NewMockAPI(ctrl)
+	m.EXPECT().GetStackID(gomock.Any(), "test").Return("stack", nil)
+	m.EXPECT().WaitStackComplete(gomock.Any(), "stack", stackUpdate).Return(nil)
+	m.EXPECT().DescribeStackEvents(gomock.Any(), "stack").Return(nil, nil).AnyTimes()
+	m.EXPECT().ListStackResources(gomock.Any(), "test").Return(nil, errors.New("Throttling: Rate exceeded")).AnyTimes()
+
+	ctx := progress.WithContextWriter(context.TODO(), &recordingWriter{})
+	backend := &ecsAPIService{aws: m}
+	assert.NilError(t, backend.WaitStackCompletion(ctx, "test", stackUpdate))
+}
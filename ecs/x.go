@@ -17,18 +17,43 @@
 package ecs
 
 const (
-	extensionSecurityGroup   = "x-aws-securitygroup"
-	extensionVPC             = "x-aws-vpc"
-	extensionPullCredentials = "x-aws-pull_credentials"
-	extensionLoadBalancer    = "x-aws-loadbalancer"
-	extensionProtocol        = "x-aws-protocol"
-	extensionCluster         = "x-aws-cluster"
-	extensionKeys            = "x-aws-keys"
-	extensionMinPercent      = "x-aws-min_percent"
-	extensionMaxPercent      = "x-aws-max_percent"
-	extensionRetention       = "x-aws-logs_retention"
-	extensionRole            = "x-aws-role"
-	extensionManagedPolicies = "x-aws-policies"
-	extensionAutoScaling     = "x-aws-autoscaling"
-	extensionCloudFormation  = "x-aws-cloudformation"
+	extensionSecurityGroup       = "x-aws-securitygroup"
+	extensionVPC                 = "x-aws-vpc"
+	extensionPullCredentials     = "x-aws-pull_credentials"
+	extensionLoadBalancer        = "x-aws-loadbalancer"
+	extensionProtocol            = "x-aws-protocol"
+	extensionCluster             = "x-aws-cluster"
+	extensionKeys                = "x-aws-keys"
+	extensionMinPercent          = "x-aws-min_percent"
+	extensionMaxPercent          = "x-aws-max_percent"
+	extensionRetention           = "x-aws-logs_retention"
+	extensionRole                = "x-aws-role"
+	extensionManagedPolicies     = "x-aws-policies"
+	extensionAutoScaling         = "x-aws-autoscaling"
+	extensionCloudFormation      = "x-aws-cloudformation"
+	extensionLoadBalancerRule    = "x-aws-alb-rule"
+	extensionHealthCheck         = "x-aws-healthcheck"
+	extensionSchedule            = "x-aws-schedule"
+	extensionMinInstances        = "x-aws-min_instances"
+	extensionMaxInstances        = "x-aws-max_instances"
+	extensionCapacity            = "x-aws-capacity"
+	extensionContent             = "x-aws-content"
+	extensionSecretSource        = "x-aws-secret-source"
+	extensionECR                 = "x-aws-ecr"
+	extensionTaskSubnets         = "x-aws-task_subnets"
+	extensionLoadBalancerSubnets = "x-aws-loadbalancer_subnets"
+	extensionPublicIP            = "x-aws-public_ip"
+	extensionServiceConnect      = "x-aws-service_connect"
+	extensionDeployment          = "x-aws-deployment"
+	extensionAccessPoint         = "x-aws-access_point"
+	extensionExportOutputs       = "x-aws-export_outputs"
 )
+
+// ExtensionDryRun is set on project by `compose up --dry-run` to preview changes to the CloudFormation stack
+const ExtensionDryRun = "x-aws-dry_run"
+
+// ExtensionDiff is set on project by `compose convert --diff` to report differences with the deployed stack
+const ExtensionDiff = "x-aws-diff"
+
+// ExtensionCost is set on project by `compose convert --cost` to estimate the monthly cost of the application
+const ExtensionCost = "x-aws-cost"
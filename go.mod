@@ -14,7 +14,7 @@ require (
 	github.com/Azure/go-autorest/autorest/to v0.4.0
 	github.com/Microsoft/go-winio v0.6.0
 	github.com/aws/aws-sdk-go v1.35.33
-	github.com/awslabs/goformation/v4 v4.15.6
+	github.com/awslabs/goformation/v4 v4.19.5
 	github.com/buger/goterm v1.0.4
 	github.com/cnabio/cnab-to-oci v0.3.1-beta1
 	github.com/compose-spec/compose-go v1.0.8